@@ -0,0 +1,278 @@
+/**
+  *  KafkaSite configuration loader
+  *
+  *  Reads the map of KafkaSite objects from the 'kafka' section of a
+  *  YAML or JSON config, applying the InitKafkaSite() defaults, any
+  *  ${VAR} references and the KAFKA_<SITE>_<FIELD> environment overlay.
+  *
+  *  Copyright (c) 2023-2026 Timothy C. Arland <tcarland at gmail dot com>
+ **/
+package config
+
+import (
+    "encoding/json"
+    "errors"
+    "fmt"
+    "os"
+    "path/filepath"
+    "reflect"
+    "regexp"
+    "sort"
+    "strconv"
+    "strings"
+
+    "gopkg.in/yaml.v3"
+)
+
+
+const (
+    FormatYAML = "yaml"
+    FormatJSON = "json"
+)
+
+// the top-level config section holding the site map, other sections
+// of the config are ignored
+const SitesSection = "kafka"
+
+
+// SiteError is a load or validation error of a single site
+type SiteError struct {
+    Site  string
+    Err   error
+}
+
+func (e *SiteError) Error() string {
+    return fmt.Sprintf("kafka site '%s': %v", e.Site, e.Err)
+}
+
+func (e *SiteError) Unwrap() error {
+    return e.Err
+}
+
+
+var envRefRegex = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// -----------------------------------
+
+/** LoadKafkaSites reads the site map from a config file, using JSON
+  * for a '.json' extension and YAML otherwise.
+ **/
+func LoadKafkaSites(path string) (map[string]*KafkaSite, error) {
+    data, err := os.ReadFile(path)
+    if err != nil {
+        return nil, err
+    }
+
+    format := FormatYAML
+    if strings.EqualFold(filepath.Ext(path), ".json") {
+        format = FormatJSON
+    }
+
+    return ParseKafkaSites(data, format)
+}
+
+
+/** ParseKafkaSites parses the 'kafka' section of a YAML or JSON config.
+  * Each site starts from the InitKafkaSite() defaults, has ${VAR}
+  * references expanded and the environment overlay applied before it
+  * is validated. Sites with errors are left out of the returned map
+  * and reported as a joined error of *SiteError, so the valid sites
+  * may still be used.
+ **/
+func ParseKafkaSites(data []byte, format string) (map[string]*KafkaSite, error) {
+    raw := make(map[string]func(*KafkaSite) error)
+
+    switch format {
+    case FormatYAML:
+        var doc struct {
+            Sites  map[string]yaml.Node  `yaml:"kafka"`
+        }
+        if err := yaml.Unmarshal(data, &doc); err != nil {
+            return nil, err
+        }
+        for name, node := range doc.Sites {
+            node := node
+            raw[name] = func(site *KafkaSite) error { return node.Decode(site) }
+        }
+    case FormatJSON:
+        var doc struct {
+            Sites  map[string]json.RawMessage  `json:"kafka"`
+        }
+        if err := json.Unmarshal(data, &doc); err != nil {
+            return nil, err
+        }
+        for name, msg := range doc.Sites {
+            msg := msg
+            raw[name] = func(site *KafkaSite) error { return json.Unmarshal(msg, site) }
+        }
+    default:
+        return nil, fmt.Errorf("unknown config format '%s'", format)
+    }
+
+    names := make([]string, 0, len(raw))
+    for name := range raw {
+        names = append(names, name)
+    }
+    sort.Strings(names)
+
+    sites := make(map[string]*KafkaSite)
+    errs  := make([]error, 0)
+
+    for _, name := range names {
+        site := NewKafkaSite("", "", "")
+
+        err := raw[name](site)
+        if err == nil {
+            err = site.expandEnv()
+        }
+        if err == nil {
+            err = site.applyEnv(name)
+        }
+        if err == nil {
+            err = site.Validate()
+        }
+
+        if err != nil {
+            errs = append(errs, &SiteError{ Site: name, Err: err })
+            continue
+        }
+        sites[name] = site
+    }
+
+    return sites, errors.Join(errs...)
+}
+
+// -----------------------------------
+
+// expandEnv replaces ${VAR} references in all string values of the site
+func (k *KafkaSite) expandEnv() error {
+    errs := make([]error, 0)
+
+    expand := func(s string) string {
+        return envRefRegex.ReplaceAllStringFunc(s, func(ref string) string {
+            name := envRefRegex.FindStringSubmatch(ref)[1]
+            val, ok := os.LookupEnv(name)
+            if ! ok {
+                errs = append(errs, fmt.Errorf("environment variable '%s' is not set", name))
+            }
+            return val
+        })
+    }
+    expandValue(reflect.ValueOf(k).Elem(), expand)
+
+    return errors.Join(errs...)
+}
+
+
+func expandValue(v reflect.Value, expand func(string) string) {
+    switch v.Kind() {
+    case reflect.String:
+        if v.CanSet() {
+            v.SetString(expand(v.String()))
+        }
+    case reflect.Ptr:
+        if ! v.IsNil() {
+            expandValue(v.Elem(), expand)
+        }
+    case reflect.Struct:
+        for i := 0; i < v.NumField(); i++ {
+            expandValue(v.Field(i), expand)
+        }
+    case reflect.Slice:
+        for i := 0; i < v.Len(); i++ {
+            expandValue(v.Index(i), expand)
+        }
+    case reflect.Map:
+        if v.Type().Elem().Kind() == reflect.String {
+            for _, key := range v.MapKeys() {
+                v.SetMapIndex(key, reflect.ValueOf(expand(v.MapIndex(key).String())).Convert(v.Type().Elem()))
+            }
+        }
+    }
+}
+
+
+// EnvPrefix returns the environment variable prefix of a site name,
+// eg. 'KAFKA_USWEST1_' for the site 'uswest1'.
+func EnvPrefix(name string) string {
+    var sb strings.Builder
+
+    sb.WriteString("KAFKA_")
+    for _, r := range strings.ToUpper(name) {
+        if (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+            sb.WriteRune(r)
+        } else {
+            sb.WriteRune('_')
+        }
+    }
+    sb.WriteRune('_')
+
+    return sb.String()
+}
+
+
+/** applyEnv overlays the scalar site fields from the environment
+  * variables named by the site prefix and the field yaml key,
+  * eg. KAFKA_USWEST1_BROKERS or KAFKA_USWEST1_REPLICATIONFACTOR.
+  * String slices are given as a comma separated list.
+ **/
+func (k *KafkaSite) applyEnv(name string) error {
+    prefix := EnvPrefix(name)
+    errs   := make([]error, 0)
+
+    v := reflect.ValueOf(k).Elem()
+    t := v.Type()
+
+    for i := 0; i < t.NumField(); i++ {
+        tag := strings.Split(t.Field(i).Tag.Get("yaml"), ",")[0]
+        if tag == "" || tag == "-" {
+            continue
+        }
+
+        envname := prefix + strings.ToUpper(tag)
+        val, ok := os.LookupEnv(envname)
+        if ! ok {
+            continue
+        }
+
+        if err := setValue(v.Field(i), val); err != nil {
+            errs = append(errs, fmt.Errorf("%s: %w", envname, err))
+        }
+    }
+
+    return errors.Join(errs...)
+}
+
+
+func setValue(f reflect.Value, val string) error {
+    switch f.Kind() {
+    case reflect.String:
+        f.SetString(val)
+    case reflect.Bool:
+        b, err := strconv.ParseBool(val)
+        if err != nil {
+            return err
+        }
+        f.SetBool(b)
+    case reflect.Int, reflect.Int32, reflect.Int64:
+        n, err := strconv.ParseInt(val, 10, 64)
+        if err != nil {
+            return err
+        }
+        f.SetInt(n)
+    case reflect.Slice:
+        if f.Type().Elem().Kind() != reflect.String {
+            return errors.New("unsupported environment override")
+        }
+        list := make([]string, 0)
+        for _, s := range strings.Split(val, ",") {
+            if s = strings.TrimSpace(s); s != "" {
+                list = append(list, s)
+            }
+        }
+        f.Set(reflect.ValueOf(list))
+    default:
+        return errors.New("unsupported environment override")
+    }
+    return nil
+}
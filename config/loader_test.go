@@ -0,0 +1,141 @@
+package config
+
+import (
+    "errors"
+    "testing"
+)
+
+
+const testYaml = `
+app:
+  name: "myapp"
+logfile: "/var/log/myapp.log"
+hosts:
+  - "host1"
+  - "host2"
+kafka:
+  uswest1:
+    brokers: "foo1:9094,foo2:9094,foo3:9094"
+    topic: "mytopic"
+    gid: "${TEST_LOADER_GID}"
+    replicationfactor: 3
+    properties:
+      client.id: "${TEST_LOADER_GID}-client"
+  lab1:
+    brokers: "localhost:9090"
+    topic: "testtopic"
+  broken:
+    topic: "notopic"
+`
+
+const testJson = `{
+    "app": "myapp",
+    "hosts": [ "host1", "host2" ],
+    "kafka": {
+        "lab1": {
+            "brokers": "localhost:9090",
+            "topic": "testtopic",
+            "gid": "test",
+            "streamreset": true
+        }
+    }
+}`
+
+
+func TestParseKafkaSites_Yaml(t *testing.T) {
+    t.Setenv("TEST_LOADER_GID", "grp1")
+    t.Setenv("KAFKA_LAB1_PARTITIONS", "6")
+
+    sites, err := ParseKafkaSites([]byte(testYaml), FormatYAML)
+
+    var serr *SiteError
+    if ! errors.As(err, &serr) || serr.Site != "broken" {
+        t.Errorf("Expected a SiteError for 'broken' but got: %v", err)
+    }
+
+    testCases := []struct {
+        name     string
+        site     string
+        gid      string
+        repl     int
+        parts    int
+    }{
+        {"Site with env references", "uswest1", "grp1", 3, 1},
+        {"Site with env overlay", "lab1", "", 1, 6},
+    }
+
+    for _, tc := range testCases {
+        t.Run(tc.name, func(t *testing.T) {
+            site, ok := sites[tc.site]
+            if ! ok {
+                t.Fatalf("Site '%v' not loaded", tc.site)
+            }
+            if site.GroupId != tc.gid {
+                t.Errorf("Expected gid '%v' but got: '%v'", tc.gid, site.GroupId)
+            }
+            if site.Replicas != tc.repl || site.Partitions != tc.parts {
+                t.Errorf("Expected %v replicas, %v partitions but got: %v, %v",
+                    tc.repl, tc.parts, site.Replicas, site.Partitions)
+            }
+            if site.CommitCount != 100 {
+                t.Errorf("Default commitcount not applied: %v", site.CommitCount)
+            }
+        })
+    }
+
+    if sites["uswest1"].Properties["client.id"] != "grp1-client" {
+        t.Errorf("Property not expanded: %v", sites["uswest1"].Properties["client.id"])
+    }
+}
+
+
+func TestParseKafkaSites_Json(t *testing.T) {
+    t.Parallel()
+
+    sites, err := ParseKafkaSites([]byte(testJson), FormatJSON)
+    if err != nil {
+        t.Fatalf("Unexpected error: %v", err)
+    }
+
+    site, ok := sites["lab1"]
+    if ! ok {
+        t.Fatalf("Site 'lab1' not loaded")
+    }
+    if ! site.DoReset || site.GroupId != "test" || site.Replicas != 1 {
+        t.Errorf("Invalid site loaded: %+v", site)
+    }
+}
+
+
+func TestParseKafkaSites_UnsetEnv(t *testing.T) {
+    t.Parallel()
+
+    data := "kafka:\n  lab1:\n    brokers: \"${TEST_LOADER_UNSET_VAR}\"\n    topic: \"test\"\n"
+
+    sites, err := ParseKafkaSites([]byte(data), FormatYAML)
+    if err == nil || len(sites) != 0 {
+        t.Errorf("Expected an error for an unset variable, got: %v", err)
+    }
+}
+
+
+func TestEnvPrefix(t *testing.T) {
+    t.Parallel()
+
+    testCases := []struct {
+        name   string
+        site   string
+        expre  string
+    }{
+        {"Simple site", "uswest1", "KAFKA_USWEST1_"},
+        {"Site with dash", "us-west-1", "KAFKA_US_WEST_1_"},
+    }
+
+    for _, tc := range testCases {
+        t.Run(tc.name, func(t *testing.T) {
+            if p := EnvPrefix(tc.site); p != tc.expre {
+                t.Errorf("Expected prefix '%v' but got: '%v'", tc.expre, p)
+            }
+        })
+    }
+}
@@ -0,0 +1,250 @@
+/**
+  *  Pass-through librdkafka client properties
+  *
+  *  Copyright (c) 2023-2026 Timothy C. Arland <tcarland at gmail dot com>
+ **/
+package config
+
+import (
+    "errors"
+    "fmt"
+    "sort"
+)
+
+
+// ClientType selects the client a set of properties is validated for
+type ClientType int
+
+const (
+    ConsumerClient ClientType = iota
+    ProducerClient
+    AdminClient
+)
+
+
+// properties with a dedicated KafkaSite field
+var managedProperties = map[string]string{
+    "bootstrap.servers":    "brokers",
+    "metadata.broker.list": "brokers",
+    "group.id":             "gid",
+    "enable.idempotence":   "idempotent",
+    "transactional.id":     "transactionalid",
+}
+
+
+// properties applying to all client types
+var commonProperties = []string{
+    "allow.auto.create.topics",
+    "api.version.fallback.ms",
+    "api.version.request",
+    "api.version.request.timeout.ms",
+    "broker.address.family",
+    "broker.address.ttl",
+    "broker.version.fallback",
+    "client.dns.lookup",
+    "client.id",
+    "client.rack",
+    "connections.max.idle.ms",
+    "debug",
+    "enable.metrics.push",
+    "enable.sasl.oauthbearer.unsecure.jwt",
+    "enable.ssl.certificate.verification",
+    "log.connection.close",
+    "log.thread.name",
+    "log_level",
+    "max.in.flight",
+    "max.in.flight.requests.per.connection",
+    "message.copy.max.bytes",
+    "message.max.bytes",
+    "metadata.max.age.ms",
+    "receive.message.max.bytes",
+    "reconnect.backoff.max.ms",
+    "reconnect.backoff.ms",
+    "retry.backoff.max.ms",
+    "retry.backoff.ms",
+    "sasl.kerberos.keytab",
+    "sasl.kerberos.kinit.cmd",
+    "sasl.kerberos.min.time.before.relogin",
+    "sasl.kerberos.principal",
+    "sasl.kerberos.service.name",
+    "sasl.mechanism",
+    "sasl.mechanisms",
+    "sasl.oauthbearer.client.id",
+    "sasl.oauthbearer.client.secret",
+    "sasl.oauthbearer.config",
+    "sasl.oauthbearer.extensions",
+    "sasl.oauthbearer.method",
+    "sasl.oauthbearer.scope",
+    "sasl.oauthbearer.token.endpoint.url",
+    "sasl.password",
+    "sasl.username",
+    "security.protocol",
+    "socket.connection.setup.timeout.ms",
+    "socket.keepalive.enable",
+    "socket.max.fails",
+    "socket.nagle.disable",
+    "socket.receive.buffer.bytes",
+    "socket.send.buffer.bytes",
+    "socket.timeout.ms",
+    "ssl.ca.location",
+    "ssl.certificate.location",
+    "ssl.cipher.suites",
+    "ssl.crl.location",
+    "ssl.curves.list",
+    "ssl.endpoint.identification.algorithm",
+    "ssl.key.location",
+    "ssl.key.password",
+    "ssl.keystore.location",
+    "ssl.keystore.password",
+    "ssl.sigalgs.list",
+    "statistics.interval.ms",
+    "topic.metadata.propagation.max.ms",
+    "topic.metadata.refresh.fast.interval.ms",
+    "topic.metadata.refresh.interval.ms",
+    "topic.metadata.refresh.sparse",
+}
+
+
+var consumerProperties = []string{
+    "auto.commit.interval.ms",
+    "auto.offset.reset",
+    "check.crcs",
+    "coordinator.query.interval.ms",
+    "enable.auto.commit",
+    "enable.auto.offset.store",
+    "enable.partition.eof",
+    "fetch.error.backoff.ms",
+    "fetch.max.bytes",
+    "fetch.message.max.bytes",
+    "fetch.min.bytes",
+    "fetch.queue.backoff.ms",
+    "fetch.wait.max.ms",
+    "group.instance.id",
+    "group.protocol",
+    "group.remote.assignor",
+    "heartbeat.interval.ms",
+    "isolation.level",
+    "max.partition.fetch.bytes",
+    "max.poll.interval.ms",
+    "partition.assignment.strategy",
+    "queued.max.messages.kbytes",
+    "queued.min.messages",
+    "session.timeout.ms",
+}
+
+
+var producerProperties = []string{
+    "acks",
+    "batch.num.messages",
+    "batch.size",
+    "compression.codec",
+    "compression.level",
+    "compression.type",
+    "delivery.timeout.ms",
+    "enable.gapless.guarantee",
+    "enable.idempotence",
+    "linger.ms",
+    "message.send.max.retries",
+    "message.timeout.ms",
+    "partitioner",
+    "queue.buffering.backpressure.threshold",
+    "queue.buffering.max.kbytes",
+    "queue.buffering.max.messages",
+    "queue.buffering.max.ms",
+    "request.required.acks",
+    "request.timeout.ms",
+    "retries",
+    "sticky.partitioning.linger.ms",
+    "transaction.timeout.ms",
+    "transactional.id",
+}
+
+
+var clientProperties = map[ClientType]map[string]bool{
+    ConsumerClient: propertySet(commonProperties, consumerProperties),
+    ProducerClient: propertySet(commonProperties, producerProperties),
+    AdminClient:    propertySet(commonProperties),
+}
+
+// -----------------------------------
+
+func propertySet(lists ...[]string) map[string]bool {
+    set := make(map[string]bool)
+    for _, list := range lists {
+        for _, key := range list {
+            set[key] = true
+        }
+    }
+    return set
+}
+
+
+func (t ClientType) String() string {
+    switch t {
+    case ConsumerClient:
+        return "consumer"
+    case ProducerClient:
+        return "producer"
+    case AdminClient:
+        return "admin"
+    }
+    return "unknown"
+}
+
+
+/** ClientProperties validates the site Properties and returns those
+  * applying to the given client type along with the Security settings.
+  * Properties belonging only to another client type are skipped, while
+  * unknown properties and those set by a dedicated KafkaSite field or
+  * the security section are returned as errors.
+ **/
+func (k *KafkaSite) ClientProperties(client ClientType) (map[string]string, error) {
+    props := make(map[string]string)
+    errs  := make([]error, 0)
+
+    keys := make([]string, 0, len(k.Properties))
+    for key := range k.Properties {
+        keys = append(keys, key)
+    }
+    sort.Strings(keys)
+
+    for _, key := range keys {
+        if field, ok := managedProperties[key]; ok {
+            errs = append(errs, fmt.Errorf("property '%s' must be set with the '%s' field", key, field))
+            continue
+        }
+        if clientProperties[client][key] {
+            props[key] = k.Properties[key]
+        } else if ! isKnownProperty(key) {
+            errs = append(errs, fmt.Errorf("unknown client property '%s'", key))
+        }
+    }
+
+    if k.Security != nil {
+        secprops, err := k.Security.ClientProperties()
+        if err != nil {
+            errs = append(errs, err)
+        }
+        for key, val := range secprops {
+            if _, ok := props[key]; ok {
+                errs = append(errs, fmt.Errorf("property '%s' conflicts with the security section", key))
+            }
+            props[key] = val
+        }
+    }
+
+    if len(errs) > 0 {
+        return nil, errors.Join(errs...)
+    }
+    return props, nil
+}
+
+
+func isKnownProperty(key string) bool {
+    for _, set := range clientProperties {
+        if set[key] {
+            return true
+        }
+    }
+    return false
+}
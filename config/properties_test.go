@@ -0,0 +1,41 @@
+package config
+
+import (
+    "testing"
+)
+
+
+func TestKafkaSite_ClientProperties(t *testing.T) {
+    t.Parallel()
+
+    testCases := []struct {
+        name    string
+        client  ClientType
+        props   map[string]string
+        excnt   int
+        exerr   bool
+    }{
+        {"Consumer properties", ConsumerClient, map[string]string{"fetch.max.bytes": "1048576", "client.id": "c1"}, 2, false},
+        {"Consumer skips producer properties", ConsumerClient, map[string]string{"linger.ms": "5", "client.id": "c1"}, 1, false},
+        {"Producer properties", ProducerClient, map[string]string{"linger.ms": "5", "fetch.max.bytes": "1048576"}, 1, false},
+        {"Admin properties", AdminClient, map[string]string{"linger.ms": "5", "socket.timeout.ms": "1000"}, 1, false},
+        {"Unknown property", ProducerClient, map[string]string{"linger.msec": "5"}, 0, true},
+        {"Managed property", ConsumerClient, map[string]string{"group.id": "grp1"}, 0, true},
+        {"Managed producer property", ProducerClient, map[string]string{"transactional.id": "txn-1"}, 0, true},
+    }
+
+    for _, tc := range testCases {
+        t.Run(tc.name, func(t *testing.T) {
+            site := NewKafkaSite("localhost:9092", "test", "grp1")
+            site.Properties = tc.props
+
+            props, err := site.ClientProperties(tc.client)
+            if (err != nil) != tc.exerr {
+                t.Errorf("Expected error %v but got: %v", tc.exerr, err)
+            }
+            if len(props) != tc.excnt {
+                t.Errorf("Expecting %v properties but got: %v", tc.excnt, len(props))
+            }
+        })
+    }
+}
@@ -0,0 +1,168 @@
+/**
+  *  KafkaSite security settings for SASL and TLS
+  *
+  *  Copyright (c) 2023-2026 Timothy C. Arland <tcarland at gmail dot com>
+ **/
+package config
+
+import (
+    "errors"
+    "fmt"
+    "os"
+    "strings"
+)
+
+
+/** KafkaSecurity defines the connection security of a site. The SASL
+  * password is given directly, read from PasswordFile or taken from the
+  * PasswordEnv environment variable, only one of which may be set.
+  * CertFile and KeyFile provide the client certificate for mTLS.
+ **/
+type KafkaSecurity struct {
+    Protocol     string `yaml:"protocol"     json:"protocol"`
+    Mechanism    string `yaml:"mechanism"    json:"mechanism"`
+    Username     string `yaml:"username"     json:"username"`
+    Password     string `yaml:"password"     json:"password"`
+    PasswordFile string `yaml:"passwordfile" json:"passwordfile"`
+    PasswordEnv  string `yaml:"passwordenv"  json:"passwordenv"`
+    CAFile       string `yaml:"cafile"       json:"cafile"`
+    CertFile     string `yaml:"certfile"     json:"certfile"`
+    KeyFile      string `yaml:"keyfile"      json:"keyfile"`
+    KeyPassword  string `yaml:"keypassword"  json:"keypassword"`
+}
+
+
+var securityProtocols = map[string]bool{
+    "PLAINTEXT":      true,
+    "SSL":            true,
+    "SASL_PLAINTEXT": true,
+    "SASL_SSL":       true,
+}
+
+var saslMechanisms = map[string]bool{
+    "PLAIN":         true,
+    "SCRAM-SHA-256": true,
+    "SCRAM-SHA-512": true,
+    "GSSAPI":        true,
+    "OAUTHBEARER":   true,
+}
+
+// -----------------------------------
+
+func (s *KafkaSecurity) isSasl() bool {
+    return strings.HasPrefix(strings.ToUpper(s.Protocol), "SASL_")
+}
+
+
+func (s *KafkaSecurity) isSsl() bool {
+    return strings.HasSuffix(strings.ToUpper(s.Protocol), "SSL")
+}
+
+
+// Validate checks for an unknown protocol or mechanism and for
+// incomplete or conflicting combinations of settings.
+func (s *KafkaSecurity) Validate() error {
+    errs  := make([]error, 0)
+    proto := strings.ToUpper(s.Protocol)
+    mech  := strings.ToUpper(s.Mechanism)
+
+    if ! securityProtocols[proto] {
+        errs = append(errs, fmt.Errorf("security protocol '%s' must be one of PLAINTEXT, SSL, SASL_PLAINTEXT or SASL_SSL", s.Protocol))
+    }
+
+    sources := 0
+    for _, src := range []string{ s.Password, s.PasswordFile, s.PasswordEnv } {
+        if src != "" {
+            sources++
+        }
+    }
+
+    if s.isSasl() {
+        if ! saslMechanisms[mech] {
+            errs = append(errs, fmt.Errorf("security mechanism '%s' must be one of PLAIN, SCRAM-SHA-256, SCRAM-SHA-512, GSSAPI or OAUTHBEARER", s.Mechanism))
+        }
+        if mech == "PLAIN" || strings.HasPrefix(mech, "SCRAM-") {
+            if s.Username == "" {
+                errs = append(errs, fmt.Errorf("security mechanism %s requires a username", mech))
+            }
+            if sources == 0 {
+                errs = append(errs, fmt.Errorf("security mechanism %s requires one of password, passwordfile or passwordenv", mech))
+            }
+        }
+    } else if s.Mechanism != "" || s.Username != "" || sources > 0 {
+        errs = append(errs, fmt.Errorf("security mechanism and credentials require a SASL protocol, not '%s'", s.Protocol))
+    }
+
+    if sources > 1 {
+        errs = append(errs, errors.New("security password, passwordfile and passwordenv are exclusive"))
+    }
+
+    if (s.CertFile == "") != (s.KeyFile == "") {
+        errs = append(errs, errors.New("security certfile and keyfile must be set together"))
+    }
+    if ! s.isSsl() && (s.CAFile != "" || s.CertFile != "" || s.KeyFile != "") {
+        errs = append(errs, fmt.Errorf("security cafile, certfile and keyfile require an SSL protocol, not '%s'", s.Protocol))
+    }
+
+    return errors.Join(errs...)
+}
+
+
+// GetPassword resolves the SASL password from its configured source
+func (s *KafkaSecurity) GetPassword() (string, error) {
+    if s.PasswordFile != "" {
+        data, err := os.ReadFile(s.PasswordFile)
+        if err != nil {
+            return "", fmt.Errorf("security passwordfile: %w", err)
+        }
+        return strings.TrimRight(string(data), "\r\n"), nil
+    }
+
+    if s.PasswordEnv != "" {
+        pw, ok := os.LookupEnv(s.PasswordEnv)
+        if ! ok {
+            return "", fmt.Errorf("security passwordenv '%s' is not set", s.PasswordEnv)
+        }
+        return pw, nil
+    }
+
+    return s.Password, nil
+}
+
+
+// ClientProperties validates the settings and returns the
+// equivalent librdkafka client properties.
+func (s *KafkaSecurity) ClientProperties() (map[string]string, error) {
+    if err := s.Validate(); err != nil {
+        return nil, err
+    }
+
+    props := map[string]string{ "security.protocol": strings.ToUpper(s.Protocol) }
+
+    if s.isSasl() {
+        props["sasl.mechanism"] = strings.ToUpper(s.Mechanism)
+        if s.Username != "" {
+            props["sasl.username"] = s.Username
+        }
+        pw, err := s.GetPassword()
+        if err != nil {
+            return nil, err
+        }
+        if pw != "" {
+            props["sasl.password"] = pw
+        }
+    }
+
+    if s.CAFile != "" {
+        props["ssl.ca.location"] = s.CAFile
+    }
+    if s.CertFile != "" {
+        props["ssl.certificate.location"] = s.CertFile
+        props["ssl.key.location"]         = s.KeyFile
+    }
+    if s.KeyPassword != "" {
+        props["ssl.key.password"] = s.KeyPassword
+    }
+
+    return props, nil
+}
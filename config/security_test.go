@@ -0,0 +1,72 @@
+package config
+
+import (
+    "os"
+    "path/filepath"
+    "testing"
+)
+
+
+func TestKafkaSecurity_Validate(t *testing.T) {
+    t.Parallel()
+
+    testCases := []struct {
+        name   string
+        sec    KafkaSecurity
+        exerr  bool
+    }{
+        {"SASL_SSL with SCRAM", KafkaSecurity{Protocol: "SASL_SSL", Mechanism: "SCRAM-SHA-512", Username: "user", Password: "secret"}, false},
+        {"SSL with mTLS", KafkaSecurity{Protocol: "ssl", CAFile: "ca.pem", CertFile: "client.pem", KeyFile: "client.key"}, false},
+        {"Unknown protocol", KafkaSecurity{Protocol: "TLS"}, true},
+        {"SASL without mechanism", KafkaSecurity{Protocol: "SASL_SSL", Username: "user", Password: "secret"}, true},
+        {"SCRAM without username", KafkaSecurity{Protocol: "SASL_SSL", Mechanism: "SCRAM-SHA-512", Password: "secret"}, true},
+        {"SCRAM without password", KafkaSecurity{Protocol: "SASL_SSL", Mechanism: "SCRAM-SHA-512", Username: "user"}, true},
+        {"Multiple password sources", KafkaSecurity{Protocol: "SASL_SSL", Mechanism: "PLAIN", Username: "user", Password: "a", PasswordEnv: "PW"}, true},
+        {"Credentials without SASL", KafkaSecurity{Protocol: "SSL", Username: "user", Password: "secret"}, true},
+        {"Cert without key", KafkaSecurity{Protocol: "SSL", CertFile: "client.pem"}, true},
+        {"CA without SSL", KafkaSecurity{Protocol: "SASL_PLAINTEXT", Mechanism: "GSSAPI", CAFile: "ca.pem"}, true},
+    }
+
+    for _, tc := range testCases {
+        t.Run(tc.name, func(t *testing.T) {
+            err := tc.sec.Validate()
+            if (err != nil) != tc.exerr {
+                t.Errorf("Expected error %v but got: %v", tc.exerr, err)
+            }
+        })
+    }
+}
+
+
+func TestKafkaSecurity_ClientProperties(t *testing.T) {
+    pwfile := filepath.Join(t.TempDir(), "password")
+    if err := os.WriteFile(pwfile, []byte("filesecret\n"), 0600); err != nil {
+        t.Fatal(err)
+    }
+    t.Setenv("TEST_KAFKA_PASSWORD", "envsecret")
+
+    testCases := []struct {
+        name   string
+        sec    KafkaSecurity
+        expw   string
+    }{
+        {"Password", KafkaSecurity{Protocol: "SASL_SSL", Mechanism: "SCRAM-SHA-512", Username: "user", Password: "secret"}, "secret"},
+        {"Password from file", KafkaSecurity{Protocol: "SASL_SSL", Mechanism: "SCRAM-SHA-512", Username: "user", PasswordFile: pwfile}, "filesecret"},
+        {"Password from env", KafkaSecurity{Protocol: "SASL_SSL", Mechanism: "SCRAM-SHA-512", Username: "user", PasswordEnv: "TEST_KAFKA_PASSWORD"}, "envsecret"},
+    }
+
+    for _, tc := range testCases {
+        t.Run(tc.name, func(t *testing.T) {
+            props, err := tc.sec.ClientProperties()
+            if err != nil {
+                t.Fatalf("Unexpected error: %v", err)
+            }
+            if props["sasl.password"] != tc.expw {
+                t.Errorf("Expected password '%v' but got: '%v'", tc.expw, props["sasl.password"])
+            }
+            if props["security.protocol"] != "SASL_SSL" {
+                t.Errorf("Invalid security.protocol: %v", props["security.protocol"])
+            }
+        })
+    }
+}
@@ -4,17 +4,50 @@
  **/ 
 package config
 
-import ()
+import (
+    "fmt"
+    "strings"
+    "time"
+)
 
 var Version string = "0.7.1"
 
+// KafkaSite StartFrom positions
+const (
+    StartCommitted = ""
+    StartEarliest  = "earliest"
+    StartLatest    = "latest"
+)
+
 type KafkaSite struct {
-    Brokers      string `yaml:"brokers"`
-    Topic        string `yaml:"topic"`
-    GroupId      string `yaml:"gid"`
-    DoReset      bool   `yaml:"streamreset"`
-    Replicas     int    `yaml:"replicationfactor"`
-    Partitions   int    `yaml:"partitions"`
+    Brokers      string            `yaml:"brokers"           json:"brokers"`
+    Topic        string            `yaml:"topic"             json:"topic"`
+    Topics       []string          `yaml:"topics"            json:"topics"`
+    GroupId      string            `yaml:"gid"               json:"gid"`
+    DoReset      bool              `yaml:"streamreset"       json:"streamreset"`
+    Replicas     int               `yaml:"replicationfactor" json:"replicationfactor"`
+    Partitions   int               `yaml:"partitions"        json:"partitions"`
+    ManualCommit bool              `yaml:"manualcommit"      json:"manualcommit"`
+    CommitCount  int               `yaml:"commitcount"       json:"commitcount"`
+    CommitMs     int               `yaml:"commitinterval"    json:"commitinterval"`
+    BatchSize    int               `yaml:"batchsize"         json:"batchsize"`
+    BatchBytes   int               `yaml:"batchbytes"        json:"batchbytes"`
+    BatchMs      int               `yaml:"batchwait"         json:"batchwait"`
+    Workers      int               `yaml:"workers"           json:"workers"`
+    LagMs        int               `yaml:"laginterval"       json:"laginterval"`
+    HighWater    int               `yaml:"highwater"         json:"highwater"`
+    LowWater     int               `yaml:"lowwater"          json:"lowwater"`
+    StartFrom    string            `yaml:"startfrom"         json:"startfrom"`
+    StartOffsets map[int32]int64   `yaml:"startoffsets"      json:"startoffsets"`
+    MaxRetries   int               `yaml:"maxretries"        json:"maxretries"`
+    RetryMs      int               `yaml:"retrybackoff"      json:"retrybackoff"`
+    DLQTopic     string            `yaml:"dlqtopic"          json:"dlqtopic"`
+    RetryTiers   []string          `yaml:"retrytiers"        json:"retrytiers"`
+    Idempotent   bool              `yaml:"idempotent"        json:"idempotent"`
+    TxnId        string            `yaml:"transactionalid"   json:"transactionalid"`
+    TopicConfigs map[string]string `yaml:"topicconfigs"      json:"topicconfigs"`
+    Properties   map[string]string `yaml:"properties"        json:"properties"`
+    Security    *KafkaSecurity     `yaml:"security"          json:"security"`
     Active       bool
 }
 
@@ -23,12 +56,122 @@ func NewKafkaSite(brokers string, topic string, gid string) *KafkaSite {
 }
 
 func (k *KafkaSite) InitKafkaSite(brokers string, topic string, gid string) *KafkaSite {
-    k.Brokers    = brokers
-    k.Topic      = topic
-    k.GroupId    = gid
-    k.DoReset    = false
-    k.Replicas   = 1
-    k.Partitions = 1
-    k.Active     = false
+    k.Brokers      = brokers
+    k.Topic        = topic
+    k.GroupId      = gid
+    k.DoReset      = false
+    k.Replicas     = 1
+    k.Partitions   = 1
+    k.ManualCommit = false
+    k.CommitCount  = 100
+    k.CommitMs     = 5000
+    k.BatchSize    = 100
+    k.BatchBytes   = 1048576
+    k.BatchMs      = 1000
+    k.Workers      = 1
+    k.LagMs        = 0
+    k.HighWater    = 500
+    k.LowWater     = 100
+    k.MaxRetries   = 0
+    k.RetryMs      = 100
+    k.DLQTopic     = ""
+    k.RetryTiers   = nil
+    k.Idempotent   = false
+    k.TxnId        = ""
+    k.TopicConfigs = make(map[string]string)
+    k.Properties   = make(map[string]string)
+    k.Active       = false
     return k
 }
+
+
+/** SetDefaults sets the counts and sizes left at zero, such as by a site
+  * unmarshalled as part of an application config, to the InitKafkaSite()
+  * defaults. It is applied by Consume(), Produce() and the Reconciler.
+ **/
+func (k *KafkaSite) SetDefaults() {
+    d := NewKafkaSite("", "", "")
+
+    for _, f := range []struct{ v *int; def int }{
+        { &k.Replicas,    d.Replicas },
+        { &k.Partitions,  d.Partitions },
+        { &k.CommitCount, d.CommitCount },
+        { &k.BatchSize,   d.BatchSize },
+        { &k.BatchBytes,  d.BatchBytes },
+        { &k.BatchMs,     d.BatchMs },
+        { &k.Workers,     d.Workers },
+    } {
+        if *f.v == 0 {
+            *f.v = f.def
+        }
+    }
+}
+
+
+/** TopicList returns the Topic followed by any additional Topics,
+  * without duplicates. Entries starting with '^' are regex patterns
+  * matching topic names on subscription.
+ **/
+func (k *KafkaSite) TopicList() []string {
+    topics := make([]string, 0, len(k.Topics) + 1)
+    seen   := make(map[string]bool)
+
+    for _, t := range append([]string{ k.Topic }, k.Topics...) {
+        if t == "" || seen[t] {
+            continue
+        }
+        seen[t] = true
+        topics  = append(topics, t)
+    }
+    return topics
+}
+
+
+// IsTopicPattern returns true if the topic is a '^' regex pattern
+func IsTopicPattern(topic string) bool {
+    return strings.HasPrefix(topic, "^")
+}
+
+
+// RetryTopic returns the name of the retry topic of a tier, eg. 'topic.retry.1m'
+func RetryTopic(topic string, tier string) string {
+    return topic + ".retry." + tier
+}
+
+
+/** RetryDelays returns the delay of each of the RetryTiers, given as
+  * positive durations such as '1m' or '10m' in order of use.
+ **/
+func (k *KafkaSite) RetryDelays() ([]time.Duration, error) {
+    delays := make([]time.Duration, 0, len(k.RetryTiers))
+
+    for _, tier := range k.RetryTiers {
+        d, err := time.ParseDuration(tier)
+        if err != nil || d <= 0 {
+            return nil, fmt.Errorf("retrytiers '%s' must be a positive duration such as '1m'", tier)
+        }
+        delays = append(delays, d)
+    }
+    return delays, nil
+}
+
+
+/** StartTime returns the timestamp given by StartFrom, either as an
+  * RFC3339 time or a duration before now such as '1h'. The bool result
+  * is false when StartFrom is not a timestamp.
+ **/
+func (k *KafkaSite) StartTime() (time.Time, bool, error) {
+    switch k.StartFrom {
+    case StartCommitted, StartEarliest, StartLatest:
+        return time.Time{}, false, nil
+    }
+
+    if t, err := time.Parse(time.RFC3339, k.StartFrom); err == nil {
+        return t, true, nil
+    }
+    if d, err := time.ParseDuration(k.StartFrom); err == nil && d > 0 {
+        return time.Now().Add(-d), true, nil
+    }
+
+    return time.Time{}, false, fmt.Errorf("startfrom '%s' must be 'earliest', 'latest', an RFC3339 time or a positive duration", k.StartFrom)
+}
@@ -0,0 +1,44 @@
+package config
+
+import (
+    "testing"
+    "time"
+)
+
+
+func TestKafkaSite_StartTime(t *testing.T) {
+    t.Parallel()
+
+    testCases := []struct {
+        name    string
+        start   string
+        istime  bool
+        exerr   bool
+    }{
+        {"Committed offsets", "", false, false},
+        {"Earliest", "earliest", false, false},
+        {"Latest", "latest", false, false},
+        {"RFC3339 timestamp", "2026-01-02T15:04:05Z", true, false},
+        {"Relative duration", "1h", true, false},
+        {"Negative duration", "-1h", false, true},
+        {"Invalid position", "yesterday", false, true},
+    }
+
+    for _, tc := range testCases {
+        t.Run(tc.name, func(t *testing.T) {
+            site := NewKafkaSite("localhost:9092", "mytopic", "grp1")
+            site.StartFrom = tc.start
+
+            ts, istime, err := site.StartTime()
+            if (err != nil) != tc.exerr {
+                t.Errorf("Expected error %v but got: %v", tc.exerr, err)
+            }
+            if istime != tc.istime {
+                t.Errorf("Expected timestamp %v but got: %v", tc.istime, istime)
+            }
+            if istime && ts.After(time.Now()) {
+                t.Errorf("Start time is in the future: %v", ts)
+            }
+        })
+    }
+}
@@ -0,0 +1,223 @@
+/**
+  *  KafkaSite validation
+  *
+  *  Copyright (c) 2023-2026 Timothy C. Arland <tcarland at gmail dot com>
+ **/
+package config
+
+import (
+    "errors"
+    "fmt"
+    "net"
+    "regexp"
+    "strconv"
+    "strings"
+)
+
+
+const (
+    MaxTopicLength = 249
+    MaxReplicas    = 32767
+    MaxPartitions  = 1000000
+    MaxWorkers     = 1024
+)
+
+var topicRegex = regexp.MustCompile(`^[a-zA-Z0-9._-]+$`)
+
+// -----------------------------------
+
+/** Validate checks the site configuration, returning a joined error
+  * listing every problem found, or nil. It covers the broker address
+  * syntax, the topic name rules, replication and partition ranges,
+  * the commit, retry tier and dead-letter settings, client properties
+  * and security settings. Counts and sizes of zero are accepted as
+  * the defaults given by SetDefaults(), and a topic is not required
+  * as records may name their own.
+ **/
+func (k *KafkaSite) Validate() error {
+    errs := make([]error, 0)
+
+    if err := ValidateBrokers(k.Brokers); err != nil {
+        errs = append(errs, err)
+    }
+    topics := k.TopicList()
+    for _, topic := range topics {
+        if err := ValidateTopic(topic); err != nil {
+            errs = append(errs, err)
+        }
+    }
+
+    if k.Replicas < 0 || k.Replicas > MaxReplicas {
+        errs = append(errs, fmt.Errorf("replicationfactor %d must be between 0 (default) and %d", k.Replicas, MaxReplicas))
+    }
+    if k.Partitions < 0 || k.Partitions > MaxPartitions {
+        errs = append(errs, fmt.Errorf("partitions %d must be between 0 (default) and %d", k.Partitions, MaxPartitions))
+    }
+
+    if k.ManualCommit {
+        if k.CommitCount < 0 {
+            errs = append(errs, fmt.Errorf("commitcount %d must be 0 (default) or more", k.CommitCount))
+        }
+        if k.CommitMs < 0 {
+            errs = append(errs, fmt.Errorf("commitinterval %d must not be negative", k.CommitMs))
+        }
+    }
+
+    if k.BatchSize < 0 || k.BatchBytes < 0 || k.BatchMs < 0 {
+        errs = append(errs, fmt.Errorf("batchsize %d, batchbytes %d and batchwait %d must be 0 (default) or more",
+            k.BatchSize, k.BatchBytes, k.BatchMs))
+    }
+
+    if k.Workers < 0 || k.Workers > MaxWorkers {
+        errs = append(errs, fmt.Errorf("workers %d must be between 0 (default) and %d", k.Workers, MaxWorkers))
+    }
+
+    if k.LagMs < 0 {
+        errs = append(errs, fmt.Errorf("laginterval %d must not be negative", k.LagMs))
+    }
+
+    if k.HighWater < 0 || k.LowWater < 0 {
+        errs = append(errs, fmt.Errorf("highwater %d and lowwater %d must not be negative", k.HighWater, k.LowWater))
+    } else if k.HighWater > 0 && k.LowWater >= k.HighWater {
+        errs = append(errs, fmt.Errorf("lowwater %d must be less than highwater %d", k.LowWater, k.HighWater))
+    }
+
+    if _, _, err := k.StartTime(); err != nil {
+        errs = append(errs, err)
+    }
+    if len(k.StartOffsets) > 0 && (k.Topic == "" || IsTopicPattern(k.Topic)) {
+        errs = append(errs, errors.New("startoffsets apply to the partitions of topic, which must be set and not a pattern"))
+    }
+    for part, off := range k.StartOffsets {
+        if part < 0 || off < 0 {
+            errs = append(errs, fmt.Errorf("startoffsets partition %d offset %d must not be negative", part, off))
+        }
+    }
+
+    if k.MaxRetries < 0 || k.RetryMs < 0 {
+        errs = append(errs, fmt.Errorf("maxretries %d and retrybackoff %d must not be negative", k.MaxRetries, k.RetryMs))
+    }
+    if k.DLQTopic != "" {
+        if IsTopicPattern(k.DLQTopic) {
+            errs = append(errs, fmt.Errorf("dlqtopic '%s' may not be a pattern", k.DLQTopic))
+        } else if err := ValidateTopic(k.DLQTopic); err != nil {
+            errs = append(errs, fmt.Errorf("dlqtopic: %v", err))
+        }
+        for _, topic := range topics {
+            if topic == k.DLQTopic {
+                errs = append(errs, fmt.Errorf("dlqtopic '%s' may not be a consumed topic", k.DLQTopic))
+            }
+        }
+    }
+
+    if len(k.RetryTiers) > 0 {
+        if _, err := k.RetryDelays(); err != nil {
+            errs = append(errs, err)
+        }
+        for _, topic := range topics {
+            if IsTopicPattern(topic) {
+                errs = append(errs, fmt.Errorf("retrytiers may not be used with topic pattern '%s'", topic))
+                continue
+            }
+            for _, tier := range k.RetryTiers {
+                if err := ValidateTopic(RetryTopic(topic, tier)); err != nil {
+                    errs = append(errs, fmt.Errorf("retrytiers: %v", err))
+                }
+            }
+        }
+    }
+
+    for name := range k.TopicConfigs {
+        if strings.TrimSpace(name) == "" {
+            errs = append(errs, errors.New("topicconfigs name must not be empty"))
+        }
+    }
+
+    // the property and security checks are common to all client types
+    if _, err := k.ClientProperties(AdminClient); err != nil {
+        errs = append(errs, err)
+    }
+
+    return errors.Join(errs...)
+}
+
+
+// ValidateConsumer validates the site for use by a Consumer, which
+// additionally requires a topic and a group id.
+func (k *KafkaSite) ValidateConsumer() error {
+    errs := make([]error, 0)
+
+    if err := k.Validate(); err != nil {
+        errs = append(errs, err)
+    }
+    if len(k.TopicList()) == 0 {
+        errs = append(errs, errors.New("topic or topics is required for a consumer"))
+    }
+    if strings.TrimSpace(k.GroupId) == "" {
+        errs = append(errs, errors.New("gid is required for a consumer"))
+    }
+
+    return errors.Join(errs...)
+}
+
+// -----------------------------------
+
+/** ValidateBrokers checks a comma separated list of broker addresses
+  * of the form 'host:port', with an optional 'protocol://' prefix
+  * and IPv6 hosts given in brackets.
+ **/
+func ValidateBrokers(brokers string) error {
+    if strings.TrimSpace(brokers) == "" {
+        return errors.New("brokers is required, eg. 'host1:9092,host2:9092'")
+    }
+
+    errs := make([]error, 0)
+
+    for _, addr := range strings.Split(brokers, ",") {
+        addr = strings.TrimSpace(addr)
+        if i := strings.Index(addr, "://"); i >= 0 {
+            addr = addr[i+3:]
+        }
+
+        host, port, err := net.SplitHostPort(addr)
+        if err != nil {
+            errs = append(errs, fmt.Errorf("broker '%s' is not a valid host:port address", addr))
+            continue
+        }
+        if host == "" {
+            errs = append(errs, fmt.Errorf("broker '%s' is missing the host", addr))
+        }
+        if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+            errs = append(errs, fmt.Errorf("broker '%s' port must be between 1 and 65535", addr))
+        }
+    }
+
+    return errors.Join(errs...)
+}
+
+
+/** ValidateTopic checks the kafka topic naming rules, a topic name
+  * is limited to 249 characters of ASCII alphanumerics, '.', '_'
+  * and '-', and may not be '.' or '..'. A '^' topic pattern must
+  * be a valid regular expression.
+ **/
+func ValidateTopic(topic string) error {
+    if IsTopicPattern(topic) {
+        if _, err := regexp.Compile(topic); err != nil {
+            return fmt.Errorf("topic pattern '%s' is not a valid regex: %v", topic, err)
+        }
+        return nil
+    }
+
+    switch {
+    case topic == "":
+        return errors.New("topic is required")
+    case topic == "." || topic == "..":
+        return fmt.Errorf("topic '%s' is not a legal name", topic)
+    case len(topic) > MaxTopicLength:
+        return fmt.Errorf("topic '%.20s...' is %d characters, the maximum is %d", topic, len(topic), MaxTopicLength)
+    case ! topicRegex.MatchString(topic):
+        return fmt.Errorf("topic '%s' may only contain ASCII alphanumerics, '.', '_' and '-'", topic)
+    }
+    return nil
+}
@@ -0,0 +1,257 @@
+package config
+
+import (
+    "strings"
+    "testing"
+)
+
+
+func TestKafkaSite_Validate(t *testing.T) {
+    t.Parallel()
+
+    testCases := []struct {
+        name     string
+        brokers  string
+        topic    string
+        repl     int
+        parts    int
+        exerrs   int
+    }{
+        {"Valid site", "foo1:9094,foo2:9094", "mytopic", 3, 3, 0},
+        {"Broker with protocol", "SSL://foo1:9094", "my.topic_1", 1, 1, 0},
+        {"IPv6 broker", "[::1]:9092", "mytopic", 1, 1, 0},
+        {"Empty brokers", "", "mytopic", 1, 1, 1},
+        {"Broker missing port", "foo1", "mytopic", 1, 1, 1},
+        {"Broker invalid port", "foo1:0,foo2:99999", "mytopic", 1, 1, 2},
+        {"Illegal topic", "foo1:9094", "my topic!", 1, 1, 1},
+        {"No topic", "foo1:9094", "", 1, 1, 0},
+        {"Default partitions and replicas", "foo1:9094", "mytopic", 0, 0, 0},
+        {"Negative partitions and replicas", "foo1:9094", "mytopic", -1, -1, 2},
+        {"Every problem", "foo1", "..", -1, -1, 4},
+    }
+
+    for _, tc := range testCases {
+        t.Run(tc.name, func(t *testing.T) {
+            site := NewKafkaSite(tc.brokers, tc.topic, "grp1")
+            site.Replicas   = tc.repl
+            site.Partitions = tc.parts
+
+            err  := site.Validate()
+            nerr := 0
+            if err != nil {
+                nerr = len(strings.Split(err.Error(), "\n"))
+            }
+            if nerr != tc.exerrs {
+                t.Errorf("Expected %v errors but got %v: %v", tc.exerrs, nerr, err)
+            }
+        })
+    }
+}
+
+
+func TestKafkaSite_ValidateConsumer(t *testing.T) {
+    t.Parallel()
+
+    testCases := []struct {
+        name   string
+        topic  string
+        gid    string
+        exerr  bool
+    }{
+        {"Consumer with group id", "mytopic", "grp1", false},
+        {"Consumer missing group id", "mytopic", "", true},
+        {"Consumer missing topic", "", "grp1", true},
+    }
+
+    for _, tc := range testCases {
+        t.Run(tc.name, func(t *testing.T) {
+            site := NewKafkaSite("localhost:9092", tc.topic, tc.gid)
+            if err := site.ValidateConsumer(); (err != nil) != tc.exerr {
+                t.Errorf("Expected error %v but got: %v", tc.exerr, err)
+            }
+        })
+    }
+}
+
+
+func TestValidateTopic_Length(t *testing.T) {
+    t.Parallel()
+
+    if err := ValidateTopic(strings.Repeat("t", MaxTopicLength)); err != nil {
+        t.Errorf("Unexpected error for a topic at the maximum length: %v", err)
+    }
+    if err := ValidateTopic(strings.Repeat("t", MaxTopicLength + 1)); err == nil {
+        t.Errorf("Expected an error for a topic over the maximum length")
+    }
+}
+
+
+func TestKafkaSite_ValidateTopics(t *testing.T) {
+    t.Parallel()
+
+    testCases := []struct {
+        name    string
+        topic   string
+        topics  []string
+        excnt   int
+        exerr   bool
+    }{
+        {"Topic list", "mytopic", []string{"other", "mytopic"}, 2, false},
+        {"Topics only", "", []string{"a", "b", "c"}, 3, false},
+        {"Topic pattern", "", []string{"^metrics\\..*"}, 1, false},
+        {"Invalid pattern", "mytopic", []string{"^metrics[.*"}, 2, true},
+        {"No topics", "", nil, 0, true},
+    }
+
+    for _, tc := range testCases {
+        t.Run(tc.name, func(t *testing.T) {
+            site := NewKafkaSite("localhost:9092", tc.topic, "grp1")
+            site.Topics = tc.topics
+
+            if n := len(site.TopicList()); n != tc.excnt {
+                t.Errorf("Expecting %v topics but got: %v", tc.excnt, n)
+            }
+            if err := site.ValidateConsumer(); (err != nil) != tc.exerr {
+                t.Errorf("Expected error %v but got: %v", tc.exerr, err)
+            }
+        })
+    }
+}
+
+
+func TestKafkaSite_ValidateDLQ(t *testing.T) {
+    t.Parallel()
+
+    testCases := []struct {
+        name     string
+        dlq      string
+        retries  int
+        exerr    bool
+    }{
+        {"No dead-letter topic", "", 3, false},
+        {"Dead-letter topic", "mytopic.dlq", 3, false},
+        {"Consumed dead-letter topic", "mytopic", 0, true},
+        {"Dead-letter pattern", "^mytopic.*", 0, true},
+        {"Negative retries", "mytopic.dlq", -1, true},
+    }
+
+    for _, tc := range testCases {
+        t.Run(tc.name, func(t *testing.T) {
+            site := NewKafkaSite("localhost:9092", "mytopic", "grp1")
+            site.DLQTopic   = tc.dlq
+            site.MaxRetries = tc.retries
+
+            if err := site.Validate(); (err != nil) != tc.exerr {
+                t.Errorf("Expected error %v but got: %v", tc.exerr, err)
+            }
+        })
+    }
+}
+
+
+func TestKafkaSite_ValidateRetryTiers(t *testing.T) {
+    t.Parallel()
+
+    testCases := []struct {
+        name    string
+        topics  []string
+        tiers   []string
+        exerr   bool
+    }{
+        {"No retry tiers", nil, nil, false},
+        {"Retry tiers", []string{"other"}, []string{"1m", "10m"}, false},
+        {"Invalid tier delay", nil, []string{"1m", "soon"}, true},
+        {"Negative tier delay", nil, []string{"-1m"}, true},
+        {"Tiers with a topic pattern", []string{"^metrics.*"}, []string{"1m"}, true},
+    }
+
+    for _, tc := range testCases {
+        t.Run(tc.name, func(t *testing.T) {
+            site := NewKafkaSite("localhost:9092", "mytopic", "grp1")
+            site.Topics     = tc.topics
+            site.RetryTiers = tc.tiers
+
+            if err := site.Validate(); (err != nil) != tc.exerr {
+                t.Errorf("Expected error %v but got: %v", tc.exerr, err)
+            }
+        })
+    }
+}
+
+
+func TestKafkaSite_ValidateWorkers(t *testing.T) {
+    t.Parallel()
+
+    testCases := []struct {
+        name     string
+        workers  int
+        exerr    bool
+    }{
+        {"Single worker", 1, false},
+        {"Worker pool", 16, false},
+        {"Default workers", 0, false},
+        {"Negative workers", -1, true},
+        {"Too many workers", MaxWorkers + 1, true},
+    }
+
+    for _, tc := range testCases {
+        t.Run(tc.name, func(t *testing.T) {
+            site := NewKafkaSite("localhost:9092", "mytopic", "grp1")
+            site.Workers = tc.workers
+
+            if err := site.Validate(); (err != nil) != tc.exerr {
+                t.Errorf("Expected error %v but got: %v", tc.exerr, err)
+            }
+        })
+    }
+}
+
+
+func TestKafkaSite_SetDefaults(t *testing.T) {
+    t.Parallel()
+
+    site := &KafkaSite{ Brokers: "localhost:9092", Workers: 4 }
+    site.SetDefaults()
+
+    if site.Replicas != 1 || site.Partitions != 1 || site.BatchSize != 100 || site.CommitCount != 100 {
+        t.Errorf("Expecting zero values to take the defaults but got: %+v", site)
+    }
+    if site.Workers != 4 {
+        t.Errorf("Expecting workers to be unchanged but got: %v", site.Workers)
+    }
+    if err := site.Validate(); err != nil {
+        t.Errorf("Unexpected error for a site with defaults: %v", err)
+    }
+}
+
+
+func TestKafkaSite_ValidateStartOffsets(t *testing.T) {
+    t.Parallel()
+
+    testCases := []struct {
+        name     string
+        topic    string
+        topics   []string
+        offsets  map[int32]int64
+        exerr    bool
+    }{
+        {"Offsets of topic", "mytopic", nil, map[int32]int64{ 0: 1200 }, false},
+        {"Offsets of topic and topics", "mytopic", []string{"other"}, map[int32]int64{ 0: 1200 }, false},
+        {"Topics only", "", []string{"a", "b"}, map[int32]int64{ 0: 1200 }, true},
+        {"Topic pattern", "^metrics\\..*", nil, map[int32]int64{ 0: 1200 }, true},
+        {"Negative offset", "mytopic", nil, map[int32]int64{ 0: -1 }, true},
+        {"Topics without offsets", "", []string{"a", "b"}, nil, false},
+    }
+
+    for _, tc := range testCases {
+        t.Run(tc.name, func(t *testing.T) {
+            site := NewKafkaSite("localhost:9092", tc.topic, "grp1")
+            site.Topics       = tc.topics
+            site.StartOffsets = tc.offsets
+
+            if err := site.Validate(); (err != nil) != tc.exerr {
+                t.Errorf("Expected error %v but got: %v", tc.exerr, err)
+            }
+        })
+    }
+}
@@ -0,0 +1,427 @@
+/** kafka.Admin
+  *
+  *  Topic administration of a cluster given by a KafkaSite: creating
+  *  topics with configs, deleting, listing and describing topics,
+  *  adding partitions and describing or altering topic configs.
+  *
+  *  Copyright (c) 2023-2026 Timothy C. Arland <tcarland at gmail dot com>
+ **/
+package kafka
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "sort"
+    "strings"
+    "sync"
+
+    "github.com/tcarland/tca-kafka-go/config"
+
+    "github.com/confluentinc/confluent-kafka-go/v2/kafka"
+)
+
+
+const metadataTimeoutMs = 10000
+
+
+// TopicSpec describes a topic to create, with optional topic configs
+// such as 'retention.ms' or 'cleanup.policy'.
+type TopicSpec struct {
+    Topic       string
+    Partitions  int
+    Replicas    int
+    Configs     map[string]string
+}
+
+
+// TopicResult is the outcome of an operation on a topic, Err is nil
+// on success.
+type TopicResult struct {
+    Topic  string
+    Err    error
+}
+
+
+// PartitionInfo describes a topic partition, Leader is -1 if the
+// partition has no leader.
+type PartitionInfo struct {
+    Partition  int32
+    Leader     int32
+    Replicas   []int32
+    ISR        []int32
+}
+
+
+// TopicInfo describes a topic, or holds the error describing it
+type TopicInfo struct {
+    Topic       string
+    Internal    bool
+    Partitions  []PartitionInfo
+    Err         error
+}
+
+
+// ConfigEntry is a topic config value and where it is set
+type ConfigEntry struct {
+    Name       string
+    Value      string
+    Source     string
+    Default    bool
+    ReadOnly   bool
+    Sensitive  bool
+}
+
+
+/** Admin is an administration client of the site brokers. The
+  * client is created on first use, returning an ErrConfig error if it
+  * cannot be, and should be released with Close().
+ **/
+type Admin struct {
+    site   *config.KafkaSite
+    admin  *kafka.AdminClient
+    lock    sync.Mutex
+}
+
+// -----------------------------------
+
+func NewAdmin(site *config.KafkaSite) *Admin {
+    return new(Admin).InitAdmin(site)
+}
+
+
+func (a *Admin) InitAdmin(site *config.KafkaSite) *Admin {
+    a.site  = site
+    a.admin = nil
+    return a
+}
+
+
+// client returns the admin client, creating it if needed
+func (a *Admin) client(op string) (*kafka.AdminClient, error) {
+    a.lock.Lock()
+    defer a.lock.Unlock()
+
+    if a.admin != nil {
+        return a.admin, nil
+    }
+
+    if err := config.ValidateBrokers(a.site.Brokers); err != nil {
+        return nil, newError(op, a.site.Brokers, ErrConfig, err)
+    }
+
+    cfg, err := newConfigMap(a.site, config.AdminClient, nil, nil)
+
+    if err != nil {
+        return nil, newError(op, a.site.Brokers, ErrConfig, err)
+    }
+
+    admin, err := kafka.NewAdminClient(cfg)
+
+    if err != nil {
+        return nil, newError(op, a.site.Brokers, ErrConfig, err)
+    }
+
+    a.admin = admin
+    return admin, nil
+}
+
+
+// Close releases the admin client, the Admin may be used again
+func (a *Admin) Close() {
+    a.lock.Lock()
+    defer a.lock.Unlock()
+
+    if a.admin != nil {
+        a.admin.Close()
+        a.admin = nil
+    }
+}
+
+
+func (a *Admin) GetSiteConfig() *config.KafkaSite {
+    return a.site
+}
+
+// -----------------------------------
+
+// Brokers returns the number of brokers in the cluster
+func (a *Admin) Brokers() (int, error) {
+    admin, err := a.client("Admin.Brokers")
+    if err != nil {
+        return 0, err
+    }
+
+    md, err := admin.GetMetadata(nil, false, metadataTimeoutMs)
+    if err != nil {
+        return 0, kafkaError("Admin.Brokers", a.site.Brokers, err)
+    }
+    return len(md.Brokers), nil
+}
+
+
+/** CreateTopics creates the given topics, returning the result of each
+  * along with their joined errors. Returns an ErrConfig error and no
+  * results if a replication factor exceeds the cluster size.
+ **/
+func (a *Admin) CreateTopics(ctx context.Context, specs ...TopicSpec) ([]TopicResult, error) {
+    admin, err := a.client("Admin.CreateTopics")
+    if err != nil {
+        return nil, err
+    }
+
+    nbrokers, err := a.Brokers()
+    if err != nil {
+        return nil, err
+    }
+
+    kspecs := make([]kafka.TopicSpecification, 0, len(specs))
+    for _, spec := range specs {
+        if spec.Replicas > nbrokers {
+            return nil, newError("Admin.CreateTopics", spec.Topic, ErrConfig,
+                fmt.Errorf("replicationfactor %d is greater than the cluster size of %d brokers", spec.Replicas, nbrokers))
+        }
+        kspecs = append(kspecs, kafka.TopicSpecification{
+            Topic:             spec.Topic,
+            NumPartitions:     spec.Partitions,
+            ReplicationFactor: spec.Replicas,
+            Config:            spec.Configs,
+        })
+    }
+
+    results, err := admin.CreateTopics(ctx, kspecs)
+    if err != nil {
+        return nil, kafkaError("Admin.CreateTopics", a.site.Brokers, err)
+    }
+    return topicResults("Admin.CreateTopics", results)
+}
+
+
+// DeleteTopics deletes the given topics, returning the result of each
+// along with their joined errors.
+func (a *Admin) DeleteTopics(ctx context.Context, topics ...string) ([]TopicResult, error) {
+    admin, err := a.client("Admin.DeleteTopics")
+    if err != nil {
+        return nil, err
+    }
+
+    results, err := admin.DeleteTopics(ctx, topics)
+    if err != nil {
+        return nil, kafkaError("Admin.DeleteTopics", a.site.Brokers, err)
+    }
+    return topicResults("Admin.DeleteTopics", results)
+}
+
+
+// ListTopics returns the sorted topic names of the cluster, excluding
+// the internal topics starting with '__'.
+func (a *Admin) ListTopics() ([]string, error) {
+    admin, err := a.client("Admin.ListTopics")
+    if err != nil {
+        return nil, err
+    }
+
+    md, err := admin.GetMetadata(nil, true, metadataTimeoutMs)
+    if err != nil {
+        return nil, kafkaError("Admin.ListTopics", a.site.Brokers, err)
+    }
+
+    topics := make([]string, 0, len(md.Topics))
+    for name := range md.Topics {
+        if ! strings.HasPrefix(name, "__") {
+            topics = append(topics, name)
+        }
+    }
+    sort.Strings(topics)
+    return topics, nil
+}
+
+
+/** DescribeTopics returns the partitions, leaders and replicas of the
+  * given topics. A topic that cannot be described, such as one that
+  * does not exist, has its Err set and is included in the joined error.
+ **/
+func (a *Admin) DescribeTopics(ctx context.Context, topics ...string) ([]TopicInfo, error) {
+    admin, err := a.client("Admin.DescribeTopics")
+    if err != nil {
+        return nil, err
+    }
+
+    res, err := admin.DescribeTopics(ctx, kafka.NewTopicCollectionOfTopicNames(topics))
+    if err != nil {
+        return nil, kafkaError("Admin.DescribeTopics", a.site.Brokers, err)
+    }
+
+    infos := make([]TopicInfo, 0, len(res.TopicDescriptions))
+    errs  := make([]error, 0)
+
+    for _, td := range res.TopicDescriptions {
+        info := TopicInfo{ Topic: td.Name, Internal: td.IsInternal }
+
+        if td.Error.Code() != kafka.ErrNoError {
+            info.Err = kafkaError("Admin.DescribeTopics", td.Name, td.Error)
+            errs = append(errs, info.Err)
+        }
+        for _, tp := range td.Partitions {
+            info.Partitions = append(info.Partitions, partitionInfo(tp))
+        }
+        infos = append(infos, info)
+    }
+    return infos, errors.Join(errs...)
+}
+
+
+// CreatePartitions increases the partitions of a topic to total
+func (a *Admin) CreatePartitions(ctx context.Context, topic string, total int) error {
+    admin, err := a.client("Admin.CreatePartitions")
+    if err != nil {
+        return err
+    }
+
+    results, err := admin.CreatePartitions(ctx, []kafka.PartitionsSpecification{ {
+        Topic:      topic,
+        IncreaseTo: total,
+    } })
+    if err != nil {
+        return kafkaError("Admin.CreatePartitions", topic, err)
+    }
+    _, err = topicResults("Admin.CreatePartitions", results)
+    return err
+}
+
+
+// DescribeConfigs returns the configs of a topic sorted by name
+func (a *Admin) DescribeConfigs(ctx context.Context, topic string) ([]ConfigEntry, error) {
+    admin, err := a.client("Admin.DescribeConfigs")
+    if err != nil {
+        return nil, err
+    }
+
+    results, err := admin.DescribeConfigs(ctx, []kafka.ConfigResource{ {
+        Type: kafka.ResourceTopic,
+        Name: topic,
+    } })
+    if err != nil {
+        return nil, kafkaError("Admin.DescribeConfigs", topic, err)
+    }
+
+    entries := make([]ConfigEntry, 0)
+    for _, res := range results {
+        if res.Error.Code() != kafka.ErrNoError {
+            return nil, kafkaError("Admin.DescribeConfigs", topic, res.Error)
+        }
+        for _, ce := range res.Config {
+            entries = append(entries, ConfigEntry{
+                Name:      ce.Name,
+                Value:     ce.Value,
+                Source:    ce.Source.String(),
+                Default:   ce.IsDefault,
+                ReadOnly:  ce.IsReadOnly,
+                Sensitive: ce.IsSensitive,
+            })
+        }
+    }
+
+    sort.Slice(entries, func(i, j int) bool {
+        return entries[i].Name < entries[j].Name
+    })
+    return entries, nil
+}
+
+
+// AlterConfigs sets the given configs of a topic, leaving its other
+// configs unchanged.
+func (a *Admin) AlterConfigs(ctx context.Context, topic string, configs map[string]string) error {
+    return a.alterConfigs(ctx, "Admin.AlterConfigs", topic, configs, kafka.AlterConfigOpTypeSet)
+}
+
+
+// ResetConfigs reverts the named configs of a topic to their defaults
+func (a *Admin) ResetConfigs(ctx context.Context, topic string, names ...string) error {
+    configs := make(map[string]string)
+    for _, name := range names {
+        configs[name] = ""
+    }
+    return a.alterConfigs(ctx, "Admin.ResetConfigs", topic, configs, kafka.AlterConfigOpTypeDelete)
+}
+
+
+func (a *Admin) alterConfigs(ctx context.Context, op string, topic string,
+                             configs map[string]string, optype kafka.AlterConfigOpType) error {
+    admin, err := a.client(op)
+    if err != nil {
+        return err
+    }
+
+    entries := make([]kafka.ConfigEntry, 0, len(configs))
+    for name, value := range configs {
+        entries = append(entries, kafka.ConfigEntry{ Name: name, Value: value, IncrementalOperation: optype })
+    }
+
+    results, err := admin.IncrementalAlterConfigs(ctx, []kafka.ConfigResource{ {
+        Type:   kafka.ResourceTopic,
+        Name:   topic,
+        Config: entries,
+    } })
+    if err != nil {
+        return kafkaError(op, topic, err)
+    }
+    for _, res := range results {
+        if res.Error.Code() != kafka.ErrNoError {
+            return kafkaError(op, topic, res.Error)
+        }
+    }
+    return nil
+}
+
+// -----------------------------------
+
+// topicResults converts the client topic results, joining their errors
+func topicResults(op string, results []kafka.TopicResult) ([]TopicResult, error) {
+    res  := make([]TopicResult, 0, len(results))
+    errs := make([]error, 0)
+
+    for _, r := range results {
+        tr := TopicResult{ Topic: r.Topic }
+        if r.Error.Code() != kafka.ErrNoError {
+            tr.Err = kafkaError(op, r.Topic, r.Error)
+            errs   = append(errs, tr.Err)
+        }
+        res = append(res, tr)
+    }
+    return res, errors.Join(errs...)
+}
+
+
+func partitionInfo(tp kafka.TopicPartitionInfo) PartitionInfo {
+    pi := PartitionInfo{ Partition: int32(tp.Partition), Leader: -1 }
+
+    if tp.Leader != nil {
+        pi.Leader = int32(tp.Leader.ID)
+    }
+    for _, n := range tp.Replicas {
+        pi.Replicas = append(pi.Replicas, int32(n.ID))
+    }
+    for _, n := range tp.Isr {
+        pi.ISR = append(pi.ISR, int32(n.ID))
+    }
+    return pi
+}
+
+
+// IsTopicExists returns true for the error of creating a topic that
+// already exists.
+func IsTopicExists(err error) bool {
+    var kerr kafka.Error
+    return errors.As(err, &kerr) && kerr.Code() == kafka.ErrTopicAlreadyExists
+}
+
+
+// ReplicationFactor returns the number of replicas of the first
+// partition of the topic, or 0 if it has no partitions.
+func (t *TopicInfo) ReplicationFactor() int {
+    if len(t.Partitions) == 0 {
+        return 0
+    }
+    return len(t.Partitions[0].Replicas)
+}
@@ -0,0 +1,77 @@
+package kafka
+
+import (
+    "context"
+    "errors"
+    "testing"
+
+    "github.com/tcarland/tca-kafka-go/config"
+
+    "github.com/confluentinc/confluent-kafka-go/v2/kafka"
+)
+
+
+func TestPartitionInfo(t *testing.T) {
+    t.Parallel()
+
+    testCases := []struct {
+        name      string
+        info      kafka.TopicPartitionInfo
+        exleader  int32
+        exrepl    int
+    }{
+        {"Leader and replicas", kafka.TopicPartitionInfo{
+            Partition: 3,
+            Leader:    &kafka.Node{ ID: 2 },
+            Replicas:  []kafka.Node{ { ID: 2 }, { ID: 1 }, { ID: 3 } },
+            Isr:       []kafka.Node{ { ID: 2 }, { ID: 1 } },
+        }, 2, 3},
+        {"No leader", kafka.TopicPartitionInfo{ Partition: 0 }, -1, 0},
+    }
+
+    for _, tc := range testCases {
+        t.Run(tc.name, func(t *testing.T) {
+            pi   := partitionInfo(tc.info)
+            info := TopicInfo{ Topic: "test", Partitions: []PartitionInfo{ pi } }
+
+            if pi.Partition != int32(tc.info.Partition) || pi.Leader != tc.exleader {
+                t.Errorf("Expecting partition %v led by %v but got: %v", tc.info.Partition, tc.exleader, pi)
+            }
+            if n := info.ReplicationFactor(); n != tc.exrepl {
+                t.Errorf("Expecting a replication factor of %v but got: %v", tc.exrepl, n)
+            }
+        })
+    }
+}
+
+
+func TestTopicResults(t *testing.T) {
+    t.Parallel()
+
+    results, err := topicResults("Admin.CreateTopics", []kafka.TopicResult{
+        { Topic: "a" },
+        { Topic: "b", Error: kafka.NewError(kafka.ErrTopicAlreadyExists, "exists", false) },
+    })
+
+    if len(results) != 2 || results[0].Err != nil || results[1].Err == nil {
+        t.Fatalf("Expecting a failed result for topic 'b' only but got: %v", results)
+    }
+    if ! IsTopicExists(results[1].Err) || ! errors.Is(err, ErrBroker) {
+        t.Errorf("Expecting a topic exists ErrBroker error but got: %v", err)
+    }
+}
+
+
+func TestAdmin_Config(t *testing.T) {
+    t.Parallel()
+
+    admin := NewAdmin(config.NewKafkaSite("", "", ""))
+    defer admin.Close()
+
+    if _, err := admin.ListTopics(); ! errors.Is(err, ErrConfig) {
+        t.Errorf("Expecting an ErrConfig error without brokers but got: %v", err)
+    }
+    if err := admin.AlterConfigs(context.Background(), "test", nil); ! errors.Is(err, ErrConfig) {
+        t.Errorf("Expecting an ErrConfig error without brokers but got: %v", err)
+    }
+}
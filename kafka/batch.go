@@ -0,0 +1,145 @@
+/** kafka.BatchHandler
+  *
+  *  Batch consumption, accumulating messages up to the site BatchSize,
+  *  BatchBytes or BatchMs wait, whichever comes first, and committing
+  *  the offsets of the whole batch once the handler succeeds.
+  *
+  *  Copyright (c) 2023-2026 Timothy C. Arland <tcarland at gmail dot com>
+ **/
+package kafka
+
+import (
+    "context"
+    "log"
+    "time"
+)
+
+
+/** BatchHandler processes a batch of messages as a unit. A nil error
+  * acknowledges every message of the batch and commits their offsets,
+  * while on error the partitions of the batch are rewound and read
+  * again from their first offset in the batch. The slice is not
+  * retained by the Consumer after HandleBatch returns.
+ **/
+type BatchHandler interface {
+    HandleBatch(ctx context.Context, msgs []*Message) error
+}
+
+
+// BatchHandlerFunc adapts an ordinary function to the BatchHandler interface
+type BatchHandlerFunc func(ctx context.Context, msgs []*Message) error
+
+func (f BatchHandlerFunc) HandleBatch(ctx context.Context, msgs []*Message) error {
+    return f(ctx, msgs)
+}
+
+// -----------------------------------
+
+/** SetBatchHandler switches Process() to batch mode, replacing the
+  * per-message Handler. Batch mode stores offsets manually, as with
+  * the site ManualCommit, and must be set before starting Consume().
+ **/
+func (c *Consumer) SetBatchHandler(handler BatchHandler) {
+    c.batch = handler
+}
+
+
+func (c *Consumer) GetBatchHandler() BatchHandler {
+    return c.batch
+}
+
+
+/** processBatches is the Process() loop of batch mode. Messages are
+  * released from the in-flight count as they join the batch, so the
+  * HighWater mark bounds the messages waiting behind the batch rather
+  * than the batch itself, which may then be larger than HighWater.
+ **/
+func (c *Consumer) processBatches(ctx context.Context) {
+    wait   := time.Duration(c.site.BatchMs) * time.Millisecond
+    batch  := make([]*Message, 0, c.site.BatchSize)
+    nbytes := 0
+
+    timer := time.NewTimer(wait)
+    timer.Stop()
+    defer timer.Stop()
+
+    flush := func() {
+        timer.Stop()
+        if len(batch) > 0 {
+            c.handleBatch(ctx, batch)
+        }
+        batch  = make([]*Message, 0, c.site.BatchSize)
+        nbytes = 0
+    }
+
+    for c.active {
+        select {
+        case <- ctx.Done():
+            log.Println("Consumer.Process() Context done")
+            c.active = false
+        case m, ok := <-c.bpc:
+            if ! ok {
+                c.active = false
+                break
+            }
+            c.release()
+            if len(batch) == 0 {
+                timer.Reset(wait)
+            }
+            batch   = append(batch, m)
+            nbytes += len(m.Key) + len(m.Value)
+
+            if len(batch) >= c.site.BatchSize || nbytes >= c.site.BatchBytes {
+                flush()
+            }
+        case <- timer.C:
+            flush()
+        }
+    }
+}
+
+
+/** handleBatch calls the BatchHandler with retries. A batch that still
+  * fails is sent to the first retry tier or the dead-letter topic
+  * message by message when configured, and the batch is acknowledged
+  * once all are delivered. Otherwise the partitions of the messages not
+  * sent on are rewound. Messages passed over are dropped from the batch.
+ **/
+func (c *Consumer) handleBatch(ctx context.Context, batch []*Message) {
+    current := batch[:0]
+    for _, m := range batch {
+        if c.passedOver(m) {
+            continue
+        }
+        current = append(current, m)
+    }
+    if batch = current; len(batch) == 0 {
+        return
+    }
+
+    attempts, err := c.retry(ctx, func() error {
+        start := time.Now()
+        err   := c.batch.HandleBatch(ctx, batch)
+        c.metrics.Handled(c.name, time.Since(start), err)
+        return err
+    })
+
+    if err != nil {
+        herr := err
+        for i, m := range batch {
+            if err = c.failed(ctx, m, herr, attempts); err != nil {
+                for _, m := range batch[i:] {
+                    c.rewind(ctx, m)
+                }
+                break
+            }
+        }
+    }
+
+    if err != nil {
+        log.Printf("Consumer.Process() batch handler error: %v", err)
+        c.sendError(newError("Consumer.Process", c.name, ErrHandler, err))
+        return
+    }
+    c.ackBatch(batch)
+}
@@ -0,0 +1,113 @@
+package kafka
+
+import (
+    "context"
+    "testing"
+    "time"
+
+    "github.com/tcarland/tca-kafka-go/config"
+)
+
+
+func TestConsumer_ProcessBatches(t *testing.T) {
+    t.Parallel()
+
+    testCases := []struct {
+        name     string
+        size     int
+        nbytes   int
+        msgcnt   int
+        msglen   int
+        exsizes  []int
+    }{
+        {"Flush on batch size", 3, 1024, 7, 10, []int{3, 3, 1}},
+        {"Flush on batch bytes", 100, 50, 5, 20, []int{3, 2}},
+        {"Flush on batch wait", 100, 1024, 2, 10, []int{2}},
+    }
+
+    for _, tc := range testCases {
+        t.Run(tc.name, func(t *testing.T) {
+            site := config.NewKafkaSite("localhost:9092", "test", "grp1")
+            site.BatchSize  = tc.size
+            site.BatchBytes = tc.nbytes
+            site.BatchMs    = 50
+            site.HighWater  = 0
+
+            sizes := make(chan int, 10)
+            c := NewConsumer("test", site)
+            c.SetBatchHandler(BatchHandlerFunc(func(ctx context.Context, msgs []*Message) error {
+                sizes <- len(msgs)
+                return nil
+            }))
+
+            ctx, cancel := context.WithCancel(context.Background())
+            defer cancel()
+            go c.Process(ctx)
+
+            for i := 0; i < tc.msgcnt; i++ {
+                c.acquire()
+                c.bpc <- &Message{ Topic: "test", Offset: int64(i), Value: make([]byte, tc.msglen) }
+            }
+
+            for _, exsz := range tc.exsizes {
+                select {
+                case sz := <-sizes:
+                    if sz != exsz {
+                        t.Errorf("Expecting a batch of %v messages but got: %v", exsz, sz)
+                    }
+                case <-time.After(time.Second):
+                    t.Fatalf("Timed out waiting for a batch of %v messages", exsz)
+                }
+            }
+
+            // messages are released as they join a batch
+            deadline := time.Now().Add(time.Second)
+            for c.InFlight() != 0 && time.Now().Before(deadline) {
+                time.Sleep(time.Millisecond)
+            }
+            if n := c.InFlight(); n != 0 {
+                t.Errorf("Expecting no in-flight messages but got: %v", n)
+            }
+        })
+    }
+}
+
+
+func TestConsumer_ProcessBatchesHighWater(t *testing.T) {
+    t.Parallel()
+
+    site := config.NewKafkaSite("localhost:9092", "test", "grp1")
+    site.BatchSize = 5
+    site.BatchMs   = 10000
+    site.HighWater = 2
+    site.LowWater  = 1
+
+    sizes := make(chan int, 1)
+    c := NewConsumer("test", site)
+    c.SetBatchHandler(BatchHandlerFunc(func(ctx context.Context, msgs []*Message) error {
+        sizes <- len(msgs)
+        return nil
+    }))
+
+    ctx, cancel := context.WithCancel(context.Background())
+    defer cancel()
+    go c.Process(ctx)
+
+    for i := 0; i < site.BatchSize; i++ {
+        c.acquire()
+        c.bpc <- &Message{ Topic: "test", Offset: int64(i) }
+    }
+
+    // the batch fills past the high water mark without waiting on batchwait
+    select {
+    case sz := <-sizes:
+        if sz != site.BatchSize {
+            t.Errorf("Expecting a batch of %v messages but got: %v", site.BatchSize, sz)
+        }
+    case <-time.After(time.Second):
+        t.Fatalf("Timed out waiting for a batch larger than the high water mark")
+    }
+    if c.IsPaused() {
+        t.Errorf("Expecting the consumer to be resumed once messages join the batch")
+    }
+}
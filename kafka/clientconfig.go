@@ -0,0 +1,40 @@
+/** kafka client configuration
+  *
+  *  Builds the confluent ConfigMap for a client from a KafkaSite.
+  *
+  *  Copyright (c) 2023-2026 Timothy C. Arland <tcarland at gmail dot com>
+ **/
+package kafka
+
+import (
+    "github.com/tcarland/tca-kafka-go/config"
+
+    "github.com/confluentinc/confluent-kafka-go/v2/kafka"
+)
+
+
+/** newConfigMap merges the client defaults, the validated site
+  * Properties and the settings managed by this package, in that order,
+  * so the site may override defaults but not the managed settings.
+ **/
+func newConfigMap(site *config.KafkaSite, client config.ClientType,
+                  defaults kafka.ConfigMap, managed kafka.ConfigMap) (*kafka.ConfigMap, error) {
+    props, err := site.ClientProperties(client)
+    if err != nil {
+        return nil, err
+    }
+
+    cfg := kafka.ConfigMap{ "bootstrap.servers": site.Brokers }
+
+    for k, v := range defaults {
+        cfg[k] = v
+    }
+    for k, v := range props {
+        cfg[k] = v
+    }
+    for k, v := range managed {
+        cfg[k] = v
+    }
+
+    return &cfg, nil
+}
@@ -0,0 +1,165 @@
+/** kafka.Consumer offset commits
+  *
+  *  Manual offset management for at-least-once delivery. When the
+  *  KafkaSite enables ManualCommit, offsets are only stored once a
+  *  message has been delivered by Process(), and stored offsets are
+  *  committed in batches by count or interval.
+  *
+  *  Copyright (c) 2023-2026 Timothy C. Arland <tcarland at gmail dot com>
+ **/
+package kafka
+
+import (
+    "errors"
+    "log"
+    "time"
+
+    "github.com/confluentinc/confluent-kafka-go/v2/kafka"
+)
+
+
+// manualCommit is true when offsets are stored by the consumer, as
+// set by the site ManualCommit or implied by a BatchHandler, Workers,
+// or by offsets committed within producer transactions.
+func (c *Consumer) manualCommit() bool {
+    return c.site.ManualCommit || c.batch != nil || c.site.Workers > 1 || c.txnOffsets
+}
+
+
+// commitConfig returns the client settings for the site commit mode
+func (c *Consumer) commitConfig() kafka.ConfigMap {
+    if ! c.manualCommit() {
+        return kafka.ConfigMap{}
+    }
+    return kafka.ConfigMap{
+        "enable.auto.commit":       false,
+        "enable.auto.offset.store": false,
+    }
+}
+
+
+// ack stores the offset following the given message position and
+// commits once the site CommitCount of stored offsets is reached.
+func (c *Consumer) ack(tp kafka.TopicPartition) {
+    if ! c.manualCommit() || c.txnOffsets {
+        return
+    }
+
+    c.lock.Lock()
+    defer c.lock.Unlock()
+
+    if c.consumer == nil {
+        return
+    }
+
+    tp.Offset++
+    if _, err := c.consumer.StoreOffsets([]kafka.TopicPartition{tp}); err != nil {
+        log.Printf("Consumer.ack() store offset error: %v", err)
+        c.sendError(kafkaError("Consumer.ack", c.name, err))
+        return
+    }
+
+    c.pending++
+    if c.pending >= c.site.CommitCount {
+        c.commitLocked()
+    }
+}
+
+
+// ackBatch stores the next offset of each partition in a handled
+// batch and commits them.
+func (c *Consumer) ackBatch(msgs []*Message) error {
+    if c.txnOffsets {
+        return nil
+    }
+    parts := NextOffsets(msgs)
+
+    c.lock.Lock()
+    defer c.lock.Unlock()
+
+    if c.consumer == nil {
+        return nil
+    }
+
+    if _, err := c.consumer.StoreOffsets(parts); err != nil {
+        log.Printf("Consumer.ackBatch() store offset error: %v", err)
+        cerr := kafkaError("Consumer.ackBatch", c.name, err)
+        c.sendError(cerr)
+        return cerr
+    }
+
+    c.pending += len(msgs)
+    return c.commitLocked()
+}
+
+
+// Commit synchronously commits all stored offsets
+func (c *Consumer) Commit() error {
+    c.lock.Lock()
+    defer c.lock.Unlock()
+
+    if c.consumer == nil {
+        return nil
+    }
+    return c.commitLocked()
+}
+
+
+func (c *Consumer) commitLocked() error {
+    if c.pending == 0 {
+        return nil
+    }
+
+    _, err := c.consumer.Commit()
+
+    var kerr kafka.Error
+    if err != nil && ! (errors.As(err, &kerr) && kerr.Code() == kafka.ErrNoOffset) {
+        log.Printf("Consumer.Commit() error: %v", err)
+        cerr := kafkaError("Consumer.Commit", c.name, err)
+        c.sendError(cerr)
+        return cerr
+    }
+
+    c.pending = 0
+    return nil
+}
+
+
+// commitLoop commits stored offsets every site CommitMs interval
+// until the done channel is closed.
+func (c *Consumer) commitLoop(done chan struct{}) {
+    interval := time.Duration(c.site.CommitMs) * time.Millisecond
+    if interval <= 0 {
+        return
+    }
+
+    ticker := time.NewTicker(interval)
+    defer ticker.Stop()
+
+    for {
+        select {
+        case <- done:
+            return
+        case <- ticker.C:
+            c.Commit()
+        }
+    }
+}
+
+
+/** closeConsumer performs the final synchronous commit, if enabled,
+  * and closes the client. The client is closed outside of the lock,
+  * as Close() runs the rebalance callback to revoke the assignment,
+  * which then finds no client and has nothing left to commit.
+ **/
+func (c *Consumer) closeConsumer() {
+    c.lock.Lock()
+    if c.manualCommit() {
+        c.commitLocked()
+    }
+    consumer  := c.consumer
+    c.consumer = nil
+    c.lock.Unlock()
+
+    consumer.Close()
+}
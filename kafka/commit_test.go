@@ -0,0 +1,97 @@
+package kafka
+
+import (
+    "context"
+    "testing"
+
+    "github.com/tcarland/tca-kafka-go/config"
+)
+
+
+func TestConsumer_ManualCommit(t *testing.T) {
+    t.Parallel()
+
+    testCases := []struct {
+        name      string
+        manual    bool
+        batch     bool
+        workers   int
+        txn       bool
+        exmanual  bool
+    }{
+        {"Client auto-commit", false, false, 1, false, false},
+        {"Site manualcommit", true, false, 1, false, true},
+        {"Batch handler", false, true, 1, false, true},
+        {"Worker pool", false, false, 4, false, true},
+        {"Transaction offsets", false, false, 1, true, true},
+    }
+
+    for _, tc := range testCases {
+        t.Run(tc.name, func(t *testing.T) {
+            site := config.NewKafkaSite("localhost:9092", "test", "grp1")
+            site.ManualCommit = tc.manual
+            site.Workers      = tc.workers
+
+            c := NewConsumer("test", site)
+            c.txnOffsets = tc.txn
+            if tc.batch {
+                c.SetBatchHandler(BatchHandlerFunc(func(ctx context.Context, msgs []*Message) error {
+                    return nil
+                }))
+            }
+
+            if c.manualCommit() != tc.exmanual {
+                t.Errorf("Expecting manual commit to be %v", tc.exmanual)
+            }
+
+            cfg := c.commitConfig()
+            if ! tc.exmanual {
+                if len(cfg) != 0 {
+                    t.Errorf("Expecting no commit settings but got: %v", cfg)
+                }
+                return
+            }
+            if cfg["enable.auto.commit"] != false || cfg["enable.auto.offset.store"] != false {
+                t.Errorf("Expecting auto commit and offset store disabled but got: %v", cfg)
+            }
+        })
+    }
+}
+
+
+func TestConsumer_AckNotStored(t *testing.T) {
+    t.Parallel()
+
+    testCases := []struct {
+        name    string
+        manual  bool
+        txn     bool
+    }{
+        {"Client auto-commit", false, false},
+        {"Transaction offsets", true, true},
+        {"Client not running", true, false},
+    }
+
+    for _, tc := range testCases {
+        t.Run(tc.name, func(t *testing.T) {
+            site := config.NewKafkaSite("localhost:9092", "test", "grp1")
+            site.ManualCommit = tc.manual
+            site.CommitCount  = 1
+
+            c := NewConsumer("test", site)
+            c.txnOffsets = tc.txn
+
+            msgs := []*Message{ { Topic: "test", Partition: 0, Offset: 5 } }
+            c.ack(msgs[0].topicPartition())
+            if err := c.ackBatch(msgs); err != nil {
+                t.Errorf("Unexpected error acknowledging a batch: %v", err)
+            }
+            if err := c.Commit(); err != nil {
+                t.Errorf("Unexpected error committing: %v", err)
+            }
+            if c.pending != 0 {
+                t.Errorf("Expecting no pending offsets but got: %v", c.pending)
+            }
+        })
+    }
+}
@@ -11,9 +11,10 @@
 package kafka
 
 import (
-    "bytes"
     "context"
+    "errors"
     "log"
+    "sync"
     "time"
 
     "github.com/tcarland/tca-kafka-go/config"
@@ -25,10 +26,32 @@ import (
 
 type Consumer struct {
     name        string
-    bpc         chan *bytes.Buffer
-    buffers    *utils.BufferPool
+    bpc         chan *Message
     msglist    *utils.SyncList
+    handler     Handler
+    batch       BatchHandler
     site       *config.KafkaSite
+    consumer   *kafka.Consumer
+    lock        sync.Mutex
+    pending     int
+    onAssigned  PartitionsFunc
+    onRevoked   PartitionsFunc
+    onLost      PartitionsFunc
+    started     map[string]bool
+    out        *Producer
+    tier        int
+    tracker    *offsetTracker
+    rewinds    *rewinder
+    lagMon     *lagMonitor
+    metrics     Metrics
+    epoch       uint64
+    processing  chan struct{}
+    txnOffsets  bool
+    flowLock    sync.Mutex
+    inflight    int
+    flowPaused  bool
+    userPaused  bool
+    errc        chan error
     reset       int
     active      bool
 }
@@ -43,35 +66,110 @@ func NewConsumer( name string, site *config.KafkaSite ) *Consumer {
 func (c *Consumer) InitConsumer ( name string, site *config.KafkaSite ) *Consumer {
     c.name    = name
     c.site    = site
-    c.bpc     = make(chan *bytes.Buffer)
-    c.buffers = utils.NewBufferPool(100)
+    c.bpc     = make(chan *Message, max(site.HighWater, 1))
     c.msglist = utils.NewSyncList()
+    c.handler = NewSyncListHandler(c.msglist)
+    c.errc    = make(chan error, 100)
+    c.started = make(map[string]bool)
+    c.out     = c.newOutProducer()
+    c.tier    = 0
+    c.tracker = newOffsetTracker()
+    c.rewinds = newRewinder()
+    c.lagMon  = newLagMonitor()
+    c.metrics = nopMetrics{}
     c.reset   = 0
     c.active  = false
     return c
 }
 
 
-// Kafka Consumer goroutine
-func (c *Consumer) Consume(ctx context.Context) {
-    consumer, err := kafka.NewConsumer(&kafka.ConfigMap{
-        "bootstrap.servers":     c.site.Brokers,
+/** Kafka Consumer goroutine. Returns an ErrConfig error if the client,
+  * or the producer of the retry and dead-letter topics, cannot be
+  * created, or an ErrFatal error if the client fails while running.
+  * Non-fatal runtime errors are sent to the Errors() channel.
+ **/
+func (c *Consumer) Consume(ctx context.Context) error {
+    // the retry and dead-letter producer and the retry consumers run
+    // on a context ended when Consume() returns, as on a fatal error
+    ctx, cancel := context.WithCancel(ctx)
+    defer cancel()
+
+    c.site.SetDefaults()
+    if err := c.site.ValidateConsumer(); err != nil {
+        close(c.bpc)
+        return newError("Consumer.Consume", c.name, ErrConfig, err)
+    }
+    if c.batch != nil && c.site.Workers > 1 {
+        close(c.bpc)
+        return newError("Consumer.Consume", c.name, ErrConfig,
+            errors.New("workers may not be used with a BatchHandler"))
+    }
+
+    // the retry and dead-letter producer is started first, so failed
+    // messages are never sent to a producer that is not running
+    if c.out != nil && c.tier == 0 {
+        if _, err := c.out.start(ctx); err != nil {
+            close(c.bpc)
+            return err
+        }
+        go c.runOut(ctx)
+    }
+
+    managed := c.commitConfig()
+    managed["group.id"] = c.site.GroupId
+
+    cfg, err := newConfigMap(c.site, config.ConsumerClient, kafka.ConfigMap{
         "broker.address.family": "v4",
-        "group.id":              c.site.GroupId,
         "auto.offset.reset":    "latest",
-    })
+    }, managed)
 
     if err != nil {
-        log.Fatal(err.Error())
+        close(c.bpc)
+        return newError("Consumer.Consume", c.name, ErrConfig, err)
     }
 
-    consumer.SubscribeTopics([]string{c.site.Topic}, nil)  
-          //.SubscribeTopics([]string{"myTopic", "^aRegex.*[Tt]opic"}, nil)
+    consumer, err := kafka.NewConsumer(cfg)
+
+    if err != nil {
+        close(c.bpc)
+        return newError("Consumer.Consume", c.name, ErrConfig, err)
+    }
+
+    err = consumer.SubscribeTopics(c.site.TopicList(), c.rebalance)
+    if err != nil {
+        close(c.bpc)
+        consumer.Close()
+        return kafkaError("Consumer.Consume", c.name, err)
+    }
 
     log.Printf("kafka.Consumer.Consume() run '%s'", c.name)
+    c.lock.Lock()
+    c.consumer    = consumer
+    c.pending     = 0
+    c.lock.Unlock()
     c.site.Active = true
     c.active      = true
 
+    done := make(chan struct{})
+    if c.manualCommit() {
+        go c.commitLoop(done)
+    }
+
+    // the lag loop uses the client, so is done before it is closed
+    lagwg := sync.WaitGroup{}
+    lagwg.Add(1)
+    go func() {
+        defer lagwg.Done()
+        c.lagLoop(done)
+    }()
+
+    retries := sync.WaitGroup{}
+    if c.out != nil && c.tier == 0 {
+        c.runRetries(ctx, &retries)
+    }
+
+    var rerr error
+
     for c.active {
         select {
         case <- ctx.Done():
@@ -82,35 +180,71 @@ func (c *Consumer) Consume(ctx context.Context) {
             }
             continue
         default:
-            b := c.buffers.Get()
-
+            c.seekRewinds()
             msg, err := consumer.ReadMessage(time.Second * 6)
         
             if err == nil {
-                b.Write(msg.Value)
-                c.bpc <- b
+                m := newMessage(msg)
+                m.epoch = c.epoch
+                m.gen   = c.tracker.generation(m.Topic, m.Partition)
+                c.metrics.Consumed(c.name, m.Topic, len(msg.Value))
+                c.acquire()
+                c.bpc <- m
             } else if err.(kafka.Error).Code() != kafka.ErrTimedOut { 
                 log.Printf("Consumer error: %v (%v)\n", err, msg)
-            } else {
-                if c.site.DoReset {
-                    c.reset++
+                kerr := kafkaError("Consumer.Consume", c.name, err)
+                if errors.Is(kerr, ErrFatal) {
+                    rerr = kerr
+                    close(c.bpc)
+                    c.active = false
+                    continue
                 }
-                c.buffers.Put(b)
+                c.sendError(kerr)
+            } else if c.site.DoReset && ! c.IsPaused() {
+                c.reset++
             }
         }
     }
     c.site.Active = false
 
+    // offsets acknowledged by Process() are committed on close
+    c.waitProcess()
+    close(done)
+    lagwg.Wait()
+
     log.Printf("Consumer.Consume() finished for '%s'", c.name)
-    consumer.Close()
+    c.closeConsumer()
+
+    // the retry consumers stop with this Consumer, fatal error or not
+    cancel()
+    retries.Wait()
+    return rerr
 }
 
 
-// Process goroutine
+/** Process goroutine, dispatches each message to the Consumer Handler,
+  * or batches of messages to the BatchHandler when one is set, or to
+  * the pool of site Workers when there are more than one. Failed
+  * handlers are retried up to the site MaxRetries and then sent to the
+  * RetryTiers or the DLQTopic, if any. Errors of messages that are not
+  * sent on are sent to the Errors() channel and, when offsets are
+  * stored manually, the partition is rewound to the failed message.
+ **/
 func (c *Consumer) Process(ctx context.Context) {
     log.Printf("kafka.Consumer.Process() run '%s'", c.name)
-    
+
+    processing := make(chan struct{})
+    defer close(processing)
+    c.lock.Lock()
+    c.processing = processing
+    c.lock.Unlock()
+
     c.active = true
+    if c.batch != nil {
+        c.processBatches(ctx)
+    } else if c.site.Workers > 1 {
+        c.processWorkers(ctx)
+    }
     for c.active {
         select {
         case <- ctx.Done():
@@ -118,32 +252,89 @@ func (c *Consumer) Process(ctx context.Context) {
             c.active = false
             continue
         default:
-            b := <-c.bpc
+            m, ok := <-c.bpc
+            if ! ok {
+                c.active = false
+                break
+            }
             if ! c.active {
                 break
             }
 
-            c.msglist.Lock()
-            if c.site.DoReset && c.reset > 2 {
-                log.Printf("Consumer.Process() -> SyncList, of %d items, reset event.", c.msglist.Size())
-                c.msglist.Clear()
-                c.reset = 0
+            c.checkReset()
+            if c.passedOver(m) {
+                c.release()
+                continue
+            }
+
+            err := c.dispatch(ctx, m)
+            c.release()
+
+            if err != nil {
+                log.Printf("Consumer.Process() handler error: %v", err)
+                c.sendError(newError("Consumer.Process", c.name, ErrHandler, err))
+                c.rewind(ctx, m)
+                continue
+            }
+            if ! c.passedOver(m) {
+                c.ack(m.topicPartition())
             }
-            str := b.String()
-            c.msglist.PushBack(str)
-            c.msglist.Unlock()
-            c.buffers.Put(b)
         }
     }
     log.Printf("Consumer.Process() finished for '%s'", c.name) 
 }
 
 
+// waitProcess waits for a running Process() to finish with the
+// messages it was handling.
+func (c *Consumer) waitProcess() {
+    c.lock.Lock()
+    processing := c.processing
+    c.lock.Unlock()
+
+    if processing != nil {
+        <-processing
+    }
+}
+
+
+// checkReset calls the Resetter of the Handler on a reset event
+func (c *Consumer) checkReset() {
+    if c.site.DoReset && c.reset > 2 {
+        if r, ok := c.handler.(Resetter); ok {
+            r.Reset()
+        }
+        c.reset = 0
+    }
+}
+
+
 func (c *Consumer) IsActive() bool {
     return c.active
 }
 
 
+// Errors returns the channel of non-fatal runtime errors. The channel
+// is never closed and errors are dropped when it is full.
+func (c *Consumer) Errors() <-chan error {
+    return c.errc
+}
+
+
+// SetHandler replaces the default SyncListHandler, and should be
+// called before starting Process().
+func (c *Consumer) SetHandler(handler Handler) {
+    c.handler = handler
+}
+
+
+func (c *Consumer) GetHandler() Handler {
+    return c.handler
+}
+
+
+// GetSyncList returns the list of consumed messages as *Message items
+// stored by the default SyncListHandler.
 func (c *Consumer) GetSyncList() *utils.SyncList {
     return c.msglist
 }
@@ -0,0 +1,128 @@
+/** kafka.Producer delivery reports
+  *
+  *  The opt-in delivery report channel and the synchronous send API,
+  *  built on the confluent per-message delivery channel.
+  *
+  *  Copyright (c) 2023-2026 Timothy C. Arland <tcarland at gmail dot com>
+ **/
+package kafka
+
+import (
+    "context"
+    "log"
+
+    "github.com/confluentinc/confluent-kafka-go/v2/kafka"
+)
+
+
+/** DeliveryReport is the final delivery result of a Record, giving
+  * the partition and offset it was written to or the delivery error.
+ **/
+type DeliveryReport struct {
+    Record     *Record
+    Topic      string
+    Partition  int32
+    Offset     int64
+    Err        error
+}
+
+// -----------------------------------
+
+func newDeliveryReport(m *kafka.Message) *DeliveryReport {
+    dr := &DeliveryReport{
+        Partition: m.TopicPartition.Partition,
+        Offset:    int64(m.TopicPartition.Offset),
+        Err:       m.TopicPartition.Error,
+    }
+    if m.TopicPartition.Topic != nil {
+        dr.Topic = *m.TopicPartition.Topic
+    }
+    if rec, ok := m.Opaque.(*Record); ok {
+        dr.Record = rec
+    }
+    return dr
+}
+
+
+/** EnableDeliveryReports creates the delivery report channel of the
+  * given size and must be called before starting Produce(). Every
+  * record sent by SendMessage() or SendRecord() is reported, so the
+  * channel must be drained by the application or delivery stalls.
+ **/
+func (p *Producer) EnableDeliveryReports(size int) <-chan *DeliveryReport {
+    p.reports = make(chan *DeliveryReport, size)
+    return p.reports
+}
+
+
+func (p *Producer) DeliveryReports() <-chan *DeliveryReport {
+    return p.reports
+}
+
+
+/** SendSync sends a Record and blocks until its delivery report is
+  * received or the context is done. The returned error is the delivery
+  * error, if any, or the context error.
+ **/
+func (p *Producer) SendSync(ctx context.Context, rec *Record) (*DeliveryReport, error) {
+    rec.done = make(chan kafka.Event, 1)
+
+    if p.transactional() {
+        p.send(rec)
+    } else {
+        select {
+        case p.bpc <- rec:
+        case <- ctx.Done():
+            return nil, ctx.Err()
+        }
+    }
+
+    select {
+    case e := <-rec.done:
+        p.delivery(e.(*kafka.Message))
+        dr := newDeliveryReport(e.(*kafka.Message))
+        if dr.Err != nil {
+            return dr, kafkaError("Producer.SendSync", p.topic, dr.Err)
+        }
+        return dr, nil
+    case <- ctx.Done():
+        return nil, ctx.Err()
+    }
+}
+
+// -----------------------------------
+
+// delivered handles a delivery event from the producer events channel
+func (p *Producer) delivered(m *kafka.Message) {
+    p.delivery(m)
+    if m.TopicPartition.Error != nil {
+        log.Printf("Delivery failed: %v\n", m.TopicPartition.Error)
+        p.sendError(kafkaError("Producer.Delivery", p.topic, m.TopicPartition.Error))
+    } else {
+        log.Printf("Delivered message to topic %s [%d] at offset %v\n",
+            *m.TopicPartition.Topic, m.TopicPartition.Partition, m.TopicPartition.Offset)
+    }
+
+    if p.reports != nil {
+        p.reports <- newDeliveryReport(m)
+    }
+}
+
+
+// produceFailed completes a record that could not be queued to the client
+func (p *Producer) produceFailed(rec *Record, err error) {
+    msg := p.newMessage(rec)
+    msg.TopicPartition.Offset = kafka.OffsetInvalid
+    msg.TopicPartition.Error  = err
+
+    if rec.done != nil {
+        rec.done <- msg
+        return
+    }
+
+    p.delivery(msg)
+    p.sendError(kafkaError("Producer.Produce", p.topic, err))
+    if p.reports != nil {
+        p.reports <- newDeliveryReport(msg)
+    }
+}
@@ -0,0 +1,89 @@
+package kafka
+
+import (
+    "context"
+    "errors"
+    "testing"
+    "time"
+
+    "github.com/tcarland/tca-kafka-go/config"
+)
+
+
+func TestProducer_SendSyncFailed(t *testing.T) {
+    t.Parallel()
+
+    site := config.NewKafkaSite("localhost:9092", "test", "")
+    site.TxnId = "txn-1"
+
+    metrics := newTestMetrics()
+    p := NewSiteProducer(site)
+    p.SetMetrics(metrics)
+
+    // a transactional producer that is not running fails the record,
+    // which is reported to the caller rather than the Errors() channel
+    dr, err := p.SendSync(context.Background(), NewRecord(nil, []byte("value")))
+    if err == nil || dr == nil || dr.Err == nil {
+        t.Fatalf("Expecting a failed delivery report but got: %v %v", dr, err)
+    }
+    if dr.Topic != "test" || dr.Offset >= 0 {
+        t.Errorf("Expecting the report of topic 'test' with no offset but got: %v", dr)
+    }
+    if metrics.count("failed") != 1 || len(p.errc) != 0 {
+        t.Errorf("Expecting a delivery failure only but got: %v, %v errors", metrics.counts, len(p.errc))
+    }
+}
+
+
+func TestProducer_SendSyncContext(t *testing.T) {
+    t.Parallel()
+
+    testCases := []struct {
+        name     string
+        timeout  time.Duration
+        exerr    error
+    }{
+        {"Context canceled", 0, context.Canceled},
+        {"Context deadline", 10 * time.Millisecond, context.DeadlineExceeded},
+    }
+
+    for _, tc := range testCases {
+        t.Run(tc.name, func(t *testing.T) {
+            ctx, cancel := context.WithCancel(context.Background())
+            if tc.timeout > 0 {
+                ctx, cancel = context.WithTimeout(context.Background(), tc.timeout)
+            } else {
+                cancel()
+            }
+            defer cancel()
+
+            // the record is never taken by a Produce() goroutine
+            p := NewProducer("localhost:9092", "test")
+            if _, err := p.SendSync(ctx, NewRecord(nil, []byte("value"))); ! errors.Is(err, tc.exerr) {
+                t.Errorf("Expecting %v but got: %v", tc.exerr, err)
+            }
+        })
+    }
+}
+
+
+func TestProducer_ProduceFailedReport(t *testing.T) {
+    t.Parallel()
+
+    p := NewProducer("localhost:9092", "test")
+    reports := p.EnableDeliveryReports(1)
+
+    p.produceFailed(NewRecord(nil, []byte("value")), errors.New("queue full"))
+
+    select {
+    case dr := <-reports:
+        if dr.Err == nil {
+            t.Errorf("Expecting the report of the failure but got: %v", dr)
+        }
+    default:
+        t.Fatalf("Expecting a delivery report")
+    }
+    if n := len(p.errc); n != 1 {
+        t.Errorf("Expecting 1 error sent but got: %v", n)
+    }
+}
@@ -0,0 +1,142 @@
+/** kafka.Consumer retries and dead-letter queue
+  *
+  *  Retries failed handlers up to the site MaxRetries, then republishes
+  *  the original message to the next retry tier or the site DLQTopic,
+  *  with headers describing the failure, so the message can be
+  *  acknowledged.
+  *
+  *  Copyright (c) 2023-2026 Timothy C. Arland <tcarland at gmail dot com>
+ **/
+package kafka
+
+import (
+    "context"
+    "errors"
+    "log"
+    "strconv"
+    "time"
+
+    "github.com/tcarland/tca-kafka-go/config"
+)
+
+
+// Headers added to messages republished to the dead-letter topic
+const (
+    HeaderDLQError     = "x-dlq-error"
+    HeaderDLQTopic     = "x-dlq-source-topic"
+    HeaderDLQPartition = "x-dlq-source-partition"
+    HeaderDLQOffset    = "x-dlq-source-offset"
+    HeaderDLQAttempts  = "x-dlq-attempts"
+)
+
+// -----------------------------------
+
+/** newOutProducer returns the Producer of the site retry and dead-letter
+  * topics, or nil if neither is configured. Records always name their
+  * topic, the Producer topic is only a default, and is left empty for
+  * a site without topics, which Consume() rejects.
+ **/
+func (c *Consumer) newOutProducer() *Producer {
+    if c.site.DLQTopic == "" && len(c.site.RetryTiers) == 0 {
+        return nil
+    }
+
+    site := *c.site
+    site.Topic      = c.site.DLQTopic
+    site.Topics     = nil
+    site.DLQTopic   = ""
+    site.RetryTiers = nil
+    site.TxnId      = ""
+
+    if topics := c.site.TopicList(); site.Topic == "" && len(topics) > 0 {
+        site.Topic = config.RetryTopic(topics[0], c.site.RetryTiers[0])
+    }
+
+    return NewSiteProducer(&site)
+}
+
+
+// runOut runs the retry and dead-letter Producer, started by Consume(),
+// until the context is done.
+func (c *Consumer) runOut(ctx context.Context) {
+    if err := c.out.Produce(ctx); err != nil {
+        log.Printf("Consumer.runOut() '%s' producer error: %v", c.name, err)
+        c.sendError(err)
+    }
+}
+
+
+/** retry calls fn until it succeeds or the site MaxRetries is reached,
+  * waiting RetryMs between attempts. Returns the number of attempts
+  * made and the last error.
+ **/
+func (c *Consumer) retry(ctx context.Context, fn func() error) (int, error) {
+    backoff  := time.Duration(c.site.RetryMs) * time.Millisecond
+    attempts := 1
+
+    err := fn()
+    for err != nil && attempts <= c.site.MaxRetries {
+        select {
+        case <- ctx.Done():
+            return attempts, errors.Join(err, ctx.Err())
+        case <- time.After(backoff):
+        }
+        attempts++
+        err = fn()
+    }
+    return attempts, err
+}
+
+
+/** dispatch calls the Handler with retries, after the not-before time
+  * of a message of a retry tier. A message that still fails is sent to
+  * the next retry tier or the dead-letter topic when configured, in
+  * which case the message is handled and dispatch returns nil.
+ **/
+func (c *Consumer) dispatch(ctx context.Context, m *Message) error {
+    if c.tier > 0 {
+        if err := waitRetry(ctx, m); err != nil {
+            return err
+        }
+    }
+
+    attempts, err := c.retry(ctx, func() error {
+        start := time.Now()
+        err   := c.handler.Handle(ctx, m)
+        c.metrics.Handled(c.name, time.Since(start), err)
+        return err
+    })
+    if err == nil {
+        return nil
+    }
+    return c.failed(ctx, m, err, attempts)
+}
+
+
+/** deadLetter republishes the message to the dead-letter topic and
+  * waits for its delivery. The source headers give the original
+  * position of a message redelivered by a retry tier, and the attempts
+  * include those of the earlier tiers. Returns nil once delivered,
+  * otherwise the handler error joined with the delivery error.
+ **/
+func (c *Consumer) deadLetter(ctx context.Context, m *Message, herr error, attempts int) error {
+    topic, partition, offset, prior := origin(m)
+
+    rec := failedRecord(m)
+    rec.Topic   = c.site.DLQTopic
+    rec.Headers = append(rec.Headers,
+        Header{ Key: HeaderDLQError,     Value: []byte(herr.Error()) },
+        Header{ Key: HeaderDLQTopic,     Value: []byte(topic) },
+        Header{ Key: HeaderDLQPartition, Value: []byte(strconv.Itoa(int(partition))) },
+        Header{ Key: HeaderDLQOffset,    Value: []byte(strconv.FormatInt(offset, 10)) },
+        Header{ Key: HeaderDLQAttempts,  Value: []byte(strconv.Itoa(prior + attempts)) },
+    )
+
+    if _, err := c.out.SendSync(ctx, rec); err != nil {
+        return errors.Join(herr, err)
+    }
+
+    log.Printf("Consumer.deadLetter() '%s' sent %s [%d] offset %d to '%s' after %d attempts",
+        c.name, topic, partition, offset, c.site.DLQTopic, prior + attempts)
+    return nil
+}
@@ -0,0 +1,157 @@
+package kafka
+
+import (
+    "context"
+    "errors"
+    "testing"
+
+    "github.com/tcarland/tca-kafka-go/config"
+)
+
+
+func TestConsumer_Retry(t *testing.T) {
+    t.Parallel()
+
+    testCases := []struct {
+        name      string
+        retries   int
+        failures  int
+        exattempt int
+        exerr     bool
+    }{
+        {"Success without retries", 0, 0, 1, false},
+        {"Failure without retries", 0, 1, 1, true},
+        {"Success after retries", 3, 2, 3, false},
+        {"Retries exhausted", 2, 5, 3, true},
+    }
+
+    for _, tc := range testCases {
+        t.Run(tc.name, func(t *testing.T) {
+            site := config.NewKafkaSite("localhost:9092", "test", "grp1")
+            site.MaxRetries = tc.retries
+            site.RetryMs    = 1
+
+            c     := NewConsumer("test", site)
+            calls := 0
+
+            attempts, err := c.retry(context.Background(), func() error {
+                calls++
+                if calls <= tc.failures {
+                    return errors.New("handler failed")
+                }
+                return nil
+            })
+
+            if attempts != tc.exattempt || calls != tc.exattempt {
+                t.Errorf("Expecting %v attempts but got: %v (%v calls)", tc.exattempt, attempts, calls)
+            }
+            if (err != nil) != tc.exerr {
+                t.Errorf("Expecting error %v but got: %v", tc.exerr, err)
+            }
+        })
+    }
+}
+
+
+func TestMessage_Record(t *testing.T) {
+    t.Parallel()
+
+    m := &Message{
+        Topic:     "test",
+        Partition: 2,
+        Offset:    42,
+        Key:       []byte("key"),
+        Value:     []byte("value"),
+        Headers:   []Header{ { Key: "trace", Value: []byte("abc") } },
+    }
+    rec := m.Record()
+    rec.Headers = append(rec.Headers, Header{ Key: HeaderDLQOffset, Value: []byte("42") })
+
+    if rec.Partition != PartitionAny || rec.Topic != "" {
+        t.Errorf("Expecting an unassigned partition and topic but got: %v '%v'", rec.Partition, rec.Topic)
+    }
+    if string(rec.Key) != "key" || string(rec.Value) != "value" || len(rec.Headers) != 2 {
+        t.Errorf("Expecting the message key, value and headers but got: %v", rec)
+    }
+    if len(m.Headers) != 1 {
+        t.Errorf("Expecting the message headers to be unchanged but got: %v", m.Headers)
+    }
+}
+
+
+func TestConsumer_NewOutProducer(t *testing.T) {
+    t.Parallel()
+
+    testCases := []struct {
+        name     string
+        dlq      string
+        tiers    []string
+        extopic  string
+    }{
+        {"No retries or dead letters", "", nil, ""},
+        {"Dead-letter topic", "test.dlq", nil, "test.dlq"},
+        {"Retry tiers", "", []string{"1m"}, "test.retry.1m"},
+        {"Retry tiers and dead letters", "test.dlq", []string{"1m"}, "test.dlq"},
+    }
+
+    for _, tc := range testCases {
+        t.Run(tc.name, func(t *testing.T) {
+            site := config.NewKafkaSite("localhost:9092", "test", "grp1")
+            site.DLQTopic   = tc.dlq
+            site.RetryTiers = tc.tiers
+
+            p := NewConsumer("test", site).newOutProducer()
+            if tc.extopic == "" {
+                if p != nil {
+                    t.Errorf("Expecting no producer but got: %v", p.topic)
+                }
+                return
+            }
+            if p == nil || p.topic != tc.extopic {
+                t.Fatalf("Expecting a producer of '%v' but got: %v", tc.extopic, p)
+            }
+            if err := p.GetSiteConfig().Validate(); err != nil {
+                t.Errorf("Unexpected error validating the producer site: %v", err)
+            }
+        })
+    }
+}
+
+
+func TestConsumer_RetryTiersNoTopic(t *testing.T) {
+    t.Parallel()
+
+    site := config.NewKafkaSite("localhost:9092", "", "grp1")
+    site.RetryTiers = []string{"1m"}
+
+    c := NewConsumer("test", site)
+    if c.out == nil || c.out.topic != "" {
+        t.Fatalf("Expecting a retry producer without a default topic but got: %v", c.out)
+    }
+    if err := c.Consume(context.Background()); ! errors.Is(err, ErrConfig) {
+        t.Errorf("Expecting an ErrConfig error for a site without topics but got: %v", err)
+    }
+}
+
+
+func TestConsumer_ConsumeOutProducer(t *testing.T) {
+    t.Parallel()
+
+    site := config.NewKafkaSite("localhost:9092", "test", "grp1")
+    site.DLQTopic = "test.dlq"
+    site.Properties["linger.ms"] = "never"
+
+    c := NewConsumer("test", site)
+
+    ctx, cancel := context.WithCancel(context.Background())
+    defer cancel()
+
+    err := c.Consume(ctx)
+    if ! errors.Is(err, ErrConfig) {
+        t.Fatalf("Expecting an ErrConfig error for the dead-letter producer but got: %v", err)
+    }
+    var kerr *Error
+    if ! errors.As(err, &kerr) || kerr.Op != "Producer.Produce" {
+        t.Errorf("Expecting the error of the dead-letter producer but got: %v", err)
+    }
+}
@@ -0,0 +1,79 @@
+/** kafka.Error
+  *
+  *  Error kinds and the wrapped Error type returned by the Consumer
+  *  and Producer run methods, or delivered on their error channels.
+  *
+  *  Copyright (c) 2023-2026 Timothy C. Arland <tcarland at gmail dot com>
+ **/
+package kafka
+
+import (
+    "errors"
+    "fmt"
+    "log"
+
+    "github.com/confluentinc/confluent-kafka-go/v2/kafka"
+)
+
+
+// Error kinds, to be tested with errors.Is()
+var (
+    ErrConfig  = errors.New("kafka configuration error")
+    ErrBroker  = errors.New("kafka broker error")
+    ErrFatal   = errors.New("kafka fatal error")
+    ErrHandler = errors.New("kafka message handler error")
+    ErrState   = errors.New("kafka client state error")
+)
+
+
+/** Error wraps the underlying error with the operation and client
+  * name it occurred in. Kind is one of the error kinds above, and both
+  * Kind and Err are matched by errors.Is() and errors.As().
+ **/
+type Error struct {
+    Op     string
+    Name   string
+    Kind   error
+    Err    error
+}
+
+// -----------------------------------
+
+func newError(op string, name string, kind error, err error) *Error {
+    return &Error{ Op: op, Name: name, Kind: kind, Err: err }
+}
+
+
+// kafkaError classifies a runtime error from the confluent client
+// as either fatal or a broker error.
+func kafkaError(op string, name string, err error) *Error {
+    var kerr kafka.Error
+
+    kind := ErrBroker
+    if errors.As(err, &kerr) && kerr.IsFatal() {
+        kind = ErrFatal
+    }
+    return newError(op, name, kind, err)
+}
+
+
+func (e *Error) Error() string {
+    return fmt.Sprintf("%s '%s': %v", e.Op, e.Name, e.Err)
+}
+
+
+func (e *Error) Unwrap() []error {
+    return []error{ e.Kind, e.Err }
+}
+
+// -----------------------------------
+
+// sendError pushes an error to the channel without blocking,
+// the error is logged and dropped if the channel is full.
+func sendError(errc chan error, err error) {
+    select {
+    case errc <- err:
+    default:
+        log.Printf("kafka error channel full, dropped: %v", err)
+    }
+}
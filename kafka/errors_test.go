@@ -0,0 +1,103 @@
+package kafka
+
+import (
+    "errors"
+    "io"
+    "testing"
+
+    "github.com/confluentinc/confluent-kafka-go/v2/kafka"
+)
+
+
+func TestKafkaError(t *testing.T) {
+    t.Parallel()
+
+    testCases := []struct {
+        name    string
+        err     error
+        exkind  error
+    }{
+        {"Fatal client error", kafka.NewError(kafka.ErrFatal, "fenced", true), ErrFatal},
+        {"Broker client error", kafka.NewError(kafka.ErrTransport, "broker down", false), ErrBroker},
+        {"Other error", io.ErrUnexpectedEOF, ErrBroker},
+    }
+
+    for _, tc := range testCases {
+        t.Run(tc.name, func(t *testing.T) {
+            err := kafkaError("Consumer.Consume", "test", tc.err)
+            if err.Kind != tc.exkind {
+                t.Errorf("Expecting kind %v but got: %v", tc.exkind, err.Kind)
+            }
+            if err.Op != "Consumer.Consume" || err.Name != "test" {
+                t.Errorf("Expecting the operation and name to be set but got: %v", err)
+            }
+        })
+    }
+}
+
+
+func TestError_Unwrap(t *testing.T) {
+    t.Parallel()
+
+    cause := kafka.NewError(kafka.ErrTransport, "broker down", false)
+    var err error = newError("Producer.Produce", "test", ErrBroker, cause)
+
+    testCases := []struct {
+        name    string
+        target  error
+        exis    bool
+    }{
+        {"Matches the kind", ErrBroker, true},
+        {"Matches the cause", cause, true},
+        {"Other kind", ErrConfig, false},
+        {"Other cause", io.EOF, false},
+    }
+
+    for _, tc := range testCases {
+        t.Run(tc.name, func(t *testing.T) {
+            if errors.Is(err, tc.target) != tc.exis {
+                t.Errorf("Expecting errors.Is() of %v to be %v", tc.target, tc.exis)
+            }
+        })
+    }
+
+    var kerr *Error
+    if ! errors.As(err, &kerr) || kerr.Kind != ErrBroker {
+        t.Errorf("Expecting errors.As() to find the *Error but got: %v", kerr)
+    }
+    var cerr kafka.Error
+    if ! errors.As(err, &cerr) || cerr.Code() != kafka.ErrTransport {
+        t.Errorf("Expecting errors.As() to find the client error but got: %v", cerr)
+    }
+    if msg := err.Error(); msg != "Producer.Produce 'test': broker down" {
+        t.Errorf("Expecting the error message to name the operation but got: %v", msg)
+    }
+}
+
+
+func TestSendError(t *testing.T) {
+    t.Parallel()
+
+    testCases := []struct {
+        name   string
+        size   int
+        sent   int
+        exlen  int
+    }{
+        {"Buffered", 2, 1, 1},
+        {"Full channel drops", 2, 4, 2},
+        {"Unbuffered drops", 0, 1, 0},
+    }
+
+    for _, tc := range testCases {
+        t.Run(tc.name, func(t *testing.T) {
+            errc := make(chan error, tc.size)
+            for i := 0; i < tc.sent; i++ {
+                sendError(errc, ErrState)
+            }
+            if n := len(errc); n != tc.exlen {
+                t.Errorf("Expecting %v errors on the channel but got: %v", tc.exlen, n)
+            }
+        })
+    }
+}
@@ -0,0 +1,138 @@
+/** kafka.Consumer flow control
+  *
+  *  Pausing and resuming of the assigned partitions, either by the
+  *  application or automatically when the number of in-flight messages
+  *  between Consume() and the Handler reaches the site HighWater mark,
+  *  resuming at the LowWater mark. Polling continues while paused so
+  *  the consumer remains in the group.
+  *
+  *  Copyright (c) 2023-2026 Timothy C. Arland <tcarland at gmail dot com>
+ **/
+package kafka
+
+import (
+    "log"
+
+    "github.com/confluentinc/confluent-kafka-go/v2/kafka"
+)
+
+
+// Pause suspends fetching of all assigned partitions until Resume()
+func (c *Consumer) Pause() error {
+    c.flowLock.Lock()
+    defer c.flowLock.Unlock()
+
+    if err := c.pauseAssignment(true); err != nil {
+        return newError("Consumer.Pause", c.name, ErrState, err)
+    }
+    c.userPaused = true
+    return nil
+}
+
+
+// Resume restarts fetching of the assigned partitions, unless they
+// remain paused by backpressure in which case they resume once the
+// in-flight messages drain to the LowWater mark.
+func (c *Consumer) Resume() error {
+    c.flowLock.Lock()
+    defer c.flowLock.Unlock()
+
+    c.userPaused = false
+    if c.flowPaused {
+        return nil
+    }
+    if err := c.pauseAssignment(false); err != nil {
+        return newError("Consumer.Resume", c.name, ErrState, err)
+    }
+    return nil
+}
+
+
+func (c *Consumer) IsPaused() bool {
+    c.flowLock.Lock()
+    defer c.flowLock.Unlock()
+    return c.userPaused || c.flowPaused
+}
+
+
+// InFlight returns the number of messages read but not yet handled
+func (c *Consumer) InFlight() int {
+    c.flowLock.Lock()
+    defer c.flowLock.Unlock()
+    return c.inflight
+}
+
+// -----------------------------------
+
+// acquire counts a message passed to Process(), pausing the
+// assignment when the HighWater mark is reached.
+func (c *Consumer) acquire() {
+    c.flowLock.Lock()
+    defer c.flowLock.Unlock()
+
+    c.inflight++
+    if c.site.HighWater <= 0 || c.flowPaused || c.inflight < c.site.HighWater {
+        return
+    }
+
+    log.Printf("Consumer '%s' pausing at %d in-flight messages", c.name, c.inflight)
+    c.flowPaused = true
+    if ! c.userPaused {
+        if err := c.pauseAssignment(true); err != nil {
+            c.sendError(kafkaError("Consumer.acquire", c.name, err))
+        }
+    }
+}
+
+
+// release counts a handled message, resuming the assignment when
+// the LowWater mark is reached.
+func (c *Consumer) release() {
+    c.flowLock.Lock()
+    defer c.flowLock.Unlock()
+
+    c.inflight--
+    if ! c.flowPaused || c.inflight > c.site.LowWater {
+        return
+    }
+
+    log.Printf("Consumer '%s' resuming at %d in-flight messages", c.name, c.inflight)
+    c.flowPaused = false
+    if ! c.userPaused {
+        if err := c.pauseAssignment(false); err != nil {
+            c.sendError(kafkaError("Consumer.release", c.name, err))
+        }
+    }
+}
+
+
+// pauseAssigned pauses newly assigned partitions when the consumer is paused
+func (c *Consumer) pauseAssigned(consumer *kafka.Consumer, parts []kafka.TopicPartition) error {
+    c.flowLock.Lock()
+    defer c.flowLock.Unlock()
+
+    if ! c.userPaused && ! c.flowPaused {
+        return nil
+    }
+    return consumer.Pause(parts)
+}
+
+
+// pauseAssignment pauses or resumes the current assignment, and is
+// called with the flowLock held.
+func (c *Consumer) pauseAssignment(pause bool) error {
+    consumer, err := c.client()
+    if err != nil {
+        return err
+    }
+
+    parts, err := consumer.Assignment()
+    if err != nil || len(parts) == 0 {
+        return err
+    }
+
+    if pause {
+        return consumer.Pause(parts)
+    }
+    return consumer.Resume(parts)
+}
@@ -0,0 +1,91 @@
+package kafka
+
+import (
+    "errors"
+    "testing"
+
+    "github.com/tcarland/tca-kafka-go/config"
+)
+
+
+func TestConsumer_FlowControl(t *testing.T) {
+    t.Parallel()
+
+    testCases := []struct {
+        name       string
+        highwater  int
+        userPaused bool
+        acquired   int
+        released   int
+        expaused   bool
+        exerrs     int
+    }{
+        {"Below high water", 3, false, 2, 0, false, 0},
+        {"Paused at high water", 3, false, 3, 0, true, 1},
+        {"Paused above low water", 3, false, 4, 2, true, 1},
+        {"Resumed at low water", 3, false, 4, 3, false, 2},
+        {"No high water", 0, false, 10, 0, false, 0},
+        {"User paused at high water", 3, true, 3, 0, true, 0},
+        {"User paused at low water", 3, true, 3, 2, false, 0},
+    }
+
+    for _, tc := range testCases {
+        t.Run(tc.name, func(t *testing.T) {
+            site := config.NewKafkaSite("localhost:9092", "test", "grp1")
+            site.HighWater = tc.highwater
+            site.LowWater  = 1
+
+            c := NewConsumer("test", site)
+            c.userPaused = tc.userPaused
+
+            for i := 0; i < tc.acquired; i++ {
+                c.acquire()
+            }
+            for i := 0; i < tc.released; i++ {
+                c.release()
+            }
+
+            if n := c.InFlight(); n != tc.acquired - tc.released {
+                t.Errorf("Expecting %v in-flight messages but got: %v", tc.acquired - tc.released, n)
+            }
+            if c.flowPaused != tc.expaused {
+                t.Errorf("Expecting flow paused to be %v", tc.expaused)
+            }
+            if c.IsPaused() != (tc.expaused || tc.userPaused) {
+                t.Errorf("Expecting paused to be %v", tc.expaused || tc.userPaused)
+            }
+
+            // the client is not running, so each pause or resume fails
+            if n := len(c.errc); n != tc.exerrs {
+                t.Errorf("Expecting %v errors but got: %v", tc.exerrs, n)
+            }
+        })
+    }
+}
+
+
+func TestConsumer_PauseResume(t *testing.T) {
+    t.Parallel()
+
+    c := NewConsumer("test", config.NewKafkaSite("localhost:9092", "test", "grp1"))
+
+    if err := c.Pause(); ! errors.Is(err, ErrState) || ! errors.Is(err, errNotRunning) {
+        t.Errorf("Expecting an ErrState error pausing a consumer not running but got: %v", err)
+    }
+    if c.IsPaused() {
+        t.Errorf("Expecting a failed Pause() not to pause the consumer")
+    }
+    if err := c.Resume(); ! errors.Is(err, ErrState) {
+        t.Errorf("Expecting an ErrState error resuming a consumer not running but got: %v", err)
+    }
+
+    // paused by backpressure, Resume() leaves the resume to the low water mark
+    c.userPaused = true
+    c.flowPaused = true
+    if err := c.Resume(); err != nil {
+        t.Errorf("Unexpected error resuming while paused by backpressure: %v", err)
+    }
+    if c.userPaused || ! c.IsPaused() {
+        t.Errorf("Expecting the consumer to remain paused by backpressure only")
+    }
+}
@@ -0,0 +1,438 @@
+/** kafka.Admin consumer groups
+  *
+  *  Consumer group administration: listing and describing groups with
+  *  their members and assignments, the lag of each partition of a
+  *  group, deleting groups and resetting the offsets of a group that
+  *  has no active members.
+  *
+  *  Copyright (c) 2023-2026 Timothy C. Arland <tcarland at gmail dot com>
+ **/
+package kafka
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "sort"
+    "time"
+
+    "github.com/tcarland/tca-kafka-go/config"
+
+    "github.com/confluentinc/confluent-kafka-go/v2/kafka"
+)
+
+
+// GroupInfo is a consumer group of the cluster and its state, such as
+// 'Stable' or 'Empty'.
+type GroupInfo struct {
+    Group   string
+    State   string
+    Simple  bool
+}
+
+
+// GroupMember is a member of a consumer group and its assigned partitions
+type GroupMember struct {
+    ClientId    string
+    ConsumerId  string
+    Host        string
+    Assignment  []TopicPartition
+}
+
+
+// GroupDescription describes a consumer group, or holds the error
+// describing it.
+type GroupDescription struct {
+    Group        string
+    State        string
+    Assignor     string
+    Coordinator  int32
+    Members      []GroupMember
+    Err          error
+}
+
+
+// GroupResult is the outcome of an operation on a group, Err is nil
+// on success.
+type GroupResult struct {
+    Group  string
+    Err    error
+}
+
+
+/** PartitionLag is the lag of a partition, being the messages between
+  * the committed offset and the high watermark. Committed is -1 and the
+  * Lag is the whole partition if there is no committed offset. For
+  * Consumer.Lag(), Committed is the consumer position and Growing is
+  * set when the lag has grown over consecutive samples.
+ **/
+type PartitionLag struct {
+    Topic      string
+    Partition  int32
+    Committed  int64
+    HighWater  int64
+    Lag        int64
+    Growing    bool
+}
+
+
+var errGroupActive = errors.New("group has active members")
+
+// -----------------------------------
+
+// ListGroups returns the consumer groups of the cluster sorted by name
+func (a *Admin) ListGroups(ctx context.Context) ([]GroupInfo, error) {
+    admin, err := a.client("Admin.ListGroups")
+    if err != nil {
+        return nil, err
+    }
+
+    res, err := admin.ListConsumerGroups(ctx)
+    if err != nil {
+        return nil, kafkaError("Admin.ListGroups", a.site.Brokers, err)
+    }
+
+    groups := make([]GroupInfo, 0, len(res.Valid))
+    for _, g := range res.Valid {
+        groups = append(groups, GroupInfo{ Group: g.GroupID, State: g.State.String(), Simple: g.IsSimpleConsumerGroup })
+    }
+    sort.Slice(groups, func(i, j int) bool {
+        return groups[i].Group < groups[j].Group
+    })
+
+    errs := make([]error, 0, len(res.Errors))
+    for _, e := range res.Errors {
+        errs = append(errs, kafkaError("Admin.ListGroups", a.site.Brokers, e))
+    }
+    return groups, errors.Join(errs...)
+}
+
+
+/** DescribeGroups returns the state, members and assignments of the
+  * given groups. A group that cannot be described has its Err set and
+  * is included in the joined error.
+ **/
+func (a *Admin) DescribeGroups(ctx context.Context, groups ...string) ([]GroupDescription, error) {
+    admin, err := a.client("Admin.DescribeGroups")
+    if err != nil {
+        return nil, err
+    }
+
+    res, err := admin.DescribeConsumerGroups(ctx, groups)
+    if err != nil {
+        return nil, kafkaError("Admin.DescribeGroups", a.site.Brokers, err)
+    }
+
+    descs := make([]GroupDescription, 0, len(res.ConsumerGroupDescriptions))
+    errs  := make([]error, 0)
+
+    for _, gd := range res.ConsumerGroupDescriptions {
+        desc := GroupDescription{
+            Group:       gd.GroupID,
+            State:       gd.State.String(),
+            Assignor:    gd.PartitionAssignor,
+            Coordinator: int32(gd.Coordinator.ID),
+        }
+        if gd.Error.Code() != kafka.ErrNoError {
+            desc.Err = kafkaError("Admin.DescribeGroups", gd.GroupID, gd.Error)
+            errs = append(errs, desc.Err)
+        }
+        for _, m := range gd.Members {
+            desc.Members = append(desc.Members, GroupMember{
+                ClientId:   m.ClientID,
+                ConsumerId: m.ConsumerID,
+                Host:       m.Host,
+                Assignment: m.Assignment.TopicPartitions,
+            })
+        }
+        descs = append(descs, desc)
+    }
+    return descs, errors.Join(errs...)
+}
+
+
+// DeleteGroups deletes the given groups, which must have no members,
+// returning the result of each along with their joined errors.
+func (a *Admin) DeleteGroups(ctx context.Context, groups ...string) ([]GroupResult, error) {
+    admin, err := a.client("Admin.DeleteGroups")
+    if err != nil {
+        return nil, err
+    }
+
+    res, err := admin.DeleteConsumerGroups(ctx, groups)
+    if err != nil {
+        return nil, kafkaError("Admin.DeleteGroups", a.site.Brokers, err)
+    }
+
+    results := make([]GroupResult, 0, len(res.ConsumerGroupResults))
+    errs    := make([]error, 0)
+
+    for _, r := range res.ConsumerGroupResults {
+        gr := GroupResult{ Group: r.Group }
+        if r.Error.Code() != kafka.ErrNoError {
+            gr.Err = kafkaError("Admin.DeleteGroups", r.Group, r.Error)
+            errs   = append(errs, gr.Err)
+        }
+        results = append(results, gr)
+    }
+    return results, errors.Join(errs...)
+}
+
+// -----------------------------------
+
+/** GroupLag returns the lag of each partition with an offset committed
+  * by the group, sorted by topic and partition. TotalLag() gives the
+  * lag of the whole group.
+ **/
+func (a *Admin) GroupLag(ctx context.Context, group string) ([]PartitionLag, error) {
+    admin, err := a.client("Admin.GroupLag")
+    if err != nil {
+        return nil, err
+    }
+
+    committed, err := a.committed(ctx, admin, "Admin.GroupLag", group)
+    if err != nil {
+        return nil, err
+    }
+
+    high, err := a.listOffsets(ctx, admin, "Admin.GroupLag", committed, kafka.LatestOffsetSpec)
+    if err != nil {
+        return nil, err
+    }
+    return partitionLag(committed, high), nil
+}
+
+
+/** ResetOffsets sets the group offsets of the given topics, or of the
+  * topics the group has committed offsets for, to the earliest or the
+  * latest offsets, given as config.StartEarliest or config.StartLatest.
+  * Returns an ErrState error if the group has active members.
+ **/
+func (a *Admin) ResetOffsets(ctx context.Context, group string, to string, topics ...string) ([]TopicPartition, error) {
+    switch to {
+    case config.StartEarliest:
+        return a.resetOffsets(ctx, "Admin.ResetOffsets", group, topics, kafka.EarliestOffsetSpec)
+    case config.StartLatest:
+        return a.resetOffsets(ctx, "Admin.ResetOffsets", group, topics, kafka.LatestOffsetSpec)
+    }
+    return nil, newError("Admin.ResetOffsets", group, ErrConfig,
+        fmt.Errorf("reset position '%s' must be '%s' or '%s'", to, config.StartEarliest, config.StartLatest))
+}
+
+
+// ResetOffsetsToTime sets the group offsets to those of the first
+// messages at or after the given time, or the latest offsets if none.
+func (a *Admin) ResetOffsetsToTime(ctx context.Context, group string, ts time.Time, topics ...string) ([]TopicPartition, error) {
+    spec := kafka.NewOffsetSpecForTimestamp(ts.UnixMilli())
+    return a.resetOffsets(ctx, "Admin.ResetOffsetsToTime", group, topics, spec)
+}
+
+
+// SetOffsets sets the group offsets to the given values, being the
+// offsets of the next messages to consume.
+func (a *Admin) SetOffsets(ctx context.Context, group string, offsets []TopicPartition) error {
+    admin, err := a.client("Admin.SetOffsets")
+    if err != nil {
+        return err
+    }
+    return a.alterOffsets(ctx, admin, "Admin.SetOffsets", group, offsets)
+}
+
+// -----------------------------------
+
+func (a *Admin) resetOffsets(ctx context.Context, op string, group string,
+                             topics []string, spec kafka.OffsetSpec) ([]TopicPartition, error) {
+    admin, err := a.client(op)
+    if err != nil {
+        return nil, err
+    }
+
+    parts := make([]kafka.TopicPartition, 0)
+    if len(topics) == 0 {
+        if parts, err = a.committed(ctx, admin, op, group); err != nil {
+            return nil, err
+        }
+    } else {
+        infos, err := a.DescribeTopics(ctx, topics...)
+        if err != nil {
+            return nil, err
+        }
+        for _, info := range infos {
+            for _, pi := range info.Partitions {
+                parts = append(parts, newTopicPartition(info.Topic, pi.Partition, int64(kafka.OffsetInvalid)))
+            }
+        }
+    }
+
+    offsets, err := a.listOffsets(ctx, admin, op, parts, spec)
+    if err != nil {
+        return nil, err
+    }
+
+    // a time after the last message has no offset, use the latest
+    missing := make([]kafka.TopicPartition, 0)
+    for _, tp := range parts {
+        if offsets[partitionKey(*tp.Topic, tp.Partition)] < 0 {
+            missing = append(missing, tp)
+        }
+    }
+    if len(missing) > 0 {
+        latest, err := a.listOffsets(ctx, admin, op, missing, kafka.LatestOffsetSpec)
+        if err != nil {
+            return nil, err
+        }
+        for key, off := range latest {
+            offsets[key] = off
+        }
+    }
+
+    reset := make([]TopicPartition, 0, len(parts))
+    for _, tp := range parts {
+        reset = append(reset, newTopicPartition(*tp.Topic, tp.Partition, offsets[partitionKey(*tp.Topic, tp.Partition)]))
+    }
+
+    if err := a.alterOffsets(ctx, admin, op, group, reset); err != nil {
+        return nil, err
+    }
+    return reset, nil
+}
+
+
+// alterOffsets commits the group offsets once the group is inactive
+func (a *Admin) alterOffsets(ctx context.Context, admin *kafka.AdminClient, op string,
+                             group string, offsets []TopicPartition) error {
+    if err := a.inactive(ctx, admin, op, group); err != nil {
+        return err
+    }
+
+    res, err := admin.AlterConsumerGroupOffsets(ctx, []kafka.ConsumerGroupTopicPartitions{ {
+        Group:      group,
+        Partitions: offsets,
+    } })
+    if err != nil {
+        return kafkaError(op, group, err)
+    }
+    for _, g := range res.ConsumerGroupsTopicPartitions {
+        for _, tp := range g.Partitions {
+            if tp.Error != nil {
+                return kafkaError(op, group, tp.Error)
+            }
+        }
+    }
+    return nil
+}
+
+
+// inactive returns an ErrState error if the group has members
+func (a *Admin) inactive(ctx context.Context, admin *kafka.AdminClient, op string, group string) error {
+    res, err := admin.DescribeConsumerGroups(ctx, []string{ group })
+    if err != nil {
+        return kafkaError(op, group, err)
+    }
+
+    for _, gd := range res.ConsumerGroupDescriptions {
+        if gd.Error.Code() != kafka.ErrNoError && gd.Error.Code() != kafka.ErrGroupIDNotFound {
+            return kafkaError(op, group, gd.Error)
+        }
+        if len(gd.Members) > 0 {
+            return newError(op, group, ErrState, fmt.Errorf("%w, state %s", errGroupActive, gd.State))
+        }
+    }
+    return nil
+}
+
+
+// committed returns the committed offsets of the group
+func (a *Admin) committed(ctx context.Context, admin *kafka.AdminClient, op string, group string) ([]kafka.TopicPartition, error) {
+    res, err := admin.ListConsumerGroupOffsets(ctx, []kafka.ConsumerGroupTopicPartitions{ { Group: group } })
+    if err != nil {
+        return nil, kafkaError(op, group, err)
+    }
+
+    parts := make([]kafka.TopicPartition, 0)
+    for _, g := range res.ConsumerGroupsTopicPartitions {
+        for _, tp := range g.Partitions {
+            if tp.Error != nil {
+                return nil, kafkaError(op, group, tp.Error)
+            }
+            parts = append(parts, tp)
+        }
+    }
+    return parts, nil
+}
+
+
+// listOffsets returns the offsets of the partitions for the given spec,
+// keyed by partitionKey().
+func (a *Admin) listOffsets(ctx context.Context, admin *kafka.AdminClient, op string,
+                            parts []kafka.TopicPartition, spec kafka.OffsetSpec) (map[string]int64, error) {
+    offsets := make(map[string]int64, len(parts))
+    if len(parts) == 0 {
+        return offsets, nil
+    }
+
+    req := make(map[kafka.TopicPartition]kafka.OffsetSpec, len(parts))
+    for _, tp := range parts {
+        req[newTopicPartition(*tp.Topic, tp.Partition, int64(kafka.OffsetInvalid))] = spec
+    }
+
+    res, err := admin.ListOffsets(ctx, req)
+    if err != nil {
+        return nil, kafkaError(op, a.site.Brokers, err)
+    }
+
+    for tp, info := range res.ResultInfos {
+        if info.Error.Code() != kafka.ErrNoError {
+            return nil, kafkaError(op, *tp.Topic, info.Error)
+        }
+        offsets[partitionKey(*tp.Topic, tp.Partition)] = int64(info.Offset)
+    }
+    return offsets, nil
+}
+
+// -----------------------------------
+
+// partitionLag returns the lag of the committed offsets against the
+// high watermarks, sorted by topic and partition.
+func partitionLag(committed []kafka.TopicPartition, high map[string]int64) []PartitionLag {
+    lags := make([]PartitionLag, 0, len(committed))
+
+    for _, tp := range committed {
+        pl := PartitionLag{
+            Topic:     *tp.Topic,
+            Partition: tp.Partition,
+            Committed: int64(tp.Offset),
+            HighWater: high[partitionKey(*tp.Topic, tp.Partition)],
+        }
+        if pl.Committed < 0 {
+            pl.Committed = -1
+            pl.Lag       = pl.HighWater
+        } else if pl.HighWater > pl.Committed {
+            pl.Lag = pl.HighWater - pl.Committed
+        }
+        lags = append(lags, pl)
+    }
+
+    sort.Slice(lags, func(i, j int) bool {
+        if lags[i].Topic != lags[j].Topic {
+            return lags[i].Topic < lags[j].Topic
+        }
+        return lags[i].Partition < lags[j].Partition
+    })
+    return lags
+}
+
+
+// TotalLag returns the sum of the partition lags
+func TotalLag(lags []PartitionLag) int64 {
+    total := int64(0)
+    for _, pl := range lags {
+        total += pl.Lag
+    }
+    return total
+}
+
+
+func newTopicPartition(topic string, partition int32, offset int64) kafka.TopicPartition {
+    return kafka.TopicPartition{ Topic: &topic, Partition: partition, Offset: kafka.Offset(offset) }
+}
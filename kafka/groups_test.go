@@ -0,0 +1,77 @@
+package kafka
+
+import (
+    "context"
+    "errors"
+    "testing"
+
+    "github.com/tcarland/tca-kafka-go/config"
+)
+
+
+func TestPartitionLag(t *testing.T) {
+    t.Parallel()
+
+    committed := []TopicPartition{
+        newTopicPartition("orders", 1, 40),
+        newTopicPartition("orders", 0, 100),
+        newTopicPartition("audit", 0, -1001),
+        newTopicPartition("orders", 2, 75),
+    }
+    high := map[string]int64{
+        partitionKey("orders", 0): 150,
+        partitionKey("orders", 1): 40,
+        partitionKey("orders", 2): 70,
+        partitionKey("audit", 0):  25,
+    }
+
+    testCases := []struct {
+        name       string
+        topic      string
+        partition  int32
+        excommit   int64
+        exlag      int64
+    }{
+        {"No committed offset", "audit", 0, -1, 25},
+        {"Behind", "orders", 0, 100, 50},
+        {"Caught up", "orders", 1, 40, 0},
+        {"Ahead of the watermark", "orders", 2, 75, 0},
+    }
+
+    lags := partitionLag(committed, high)
+
+    if len(lags) != len(testCases) {
+        t.Fatalf("Expecting %d partitions but got: %v", len(testCases), lags)
+    }
+    for i, tc := range testCases {
+        t.Run(tc.name, func(t *testing.T) {
+            pl := lags[i]
+            if pl.Topic != tc.topic || pl.Partition != tc.partition {
+                t.Fatalf("Expecting %s [%d] but got: %v", tc.topic, tc.partition, pl)
+            }
+            if pl.Committed != tc.excommit || pl.Lag != tc.exlag {
+                t.Errorf("Expecting committed %v and lag %v but got: %v", tc.excommit, tc.exlag, pl)
+            }
+        })
+    }
+
+    if total := TotalLag(lags); total != 75 {
+        t.Errorf("Expecting a total lag of 75 but got: %v", total)
+    }
+}
+
+
+func TestAdmin_GroupConfig(t *testing.T) {
+    t.Parallel()
+
+    admin := NewAdmin(config.NewKafkaSite("", "", ""))
+    defer admin.Close()
+    ctx := context.Background()
+
+    if _, err := admin.ListGroups(ctx); ! errors.Is(err, ErrConfig) {
+        t.Errorf("Expecting an ErrConfig error without brokers but got: %v", err)
+    }
+    if _, err := admin.ResetOffsets(ctx, "test", "committed"); ! errors.Is(err, ErrConfig) {
+        t.Errorf("Expecting an ErrConfig error for the reset position but got: %v", err)
+    }
+}
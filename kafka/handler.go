@@ -0,0 +1,77 @@
+/** kafka.Handler
+  *
+  *  The message Handler interface dispatched to by Consumer.Process(),
+  *  and the default SyncListHandler sink.
+  *
+  *  Copyright (c) 2023-2026 Timothy C. Arland <tcarland at gmail dot com>
+ **/
+package kafka
+
+import (
+    "context"
+    "log"
+
+    "github.com/tcarland/tca-kafka-go/utils"
+)
+
+
+/** Handler processes each message delivered by the Consumer. A nil
+  * error acknowledges the message, allowing its offset to be committed
+  * when the site uses ManualCommit. With ManualCommit, a message that
+  * still fails and is not sent to a retry tier or dead-letter topic
+  * rewinds its partition, and is delivered again. Handle is called
+  * from the Process() goroutine and should not retain the context.
+ **/
+type Handler interface {
+    Handle(ctx context.Context, msg *Message) error
+}
+
+
+// HandlerFunc adapts an ordinary function to the Handler interface
+type HandlerFunc func(ctx context.Context, msg *Message) error
+
+func (f HandlerFunc) Handle(ctx context.Context, msg *Message) error {
+    return f(ctx, msg)
+}
+
+
+// Resetter is implemented by handlers that support the KafkaSite
+// streamreset option, Reset is called on a reset event.
+type Resetter interface {
+    Reset()
+}
+
+// -----------------------------------
+
+/** SyncListHandler appends each message to a SyncList and is the
+  * default Consumer handler.
+ **/
+type SyncListHandler struct {
+    list  *utils.SyncList
+}
+
+
+func NewSyncListHandler(list *utils.SyncList) *SyncListHandler {
+    return &SyncListHandler{ list: list }
+}
+
+
+func (h *SyncListHandler) Handle(ctx context.Context, msg *Message) error {
+    h.list.Lock()
+    h.list.PushBack(msg)
+    h.list.Unlock()
+    return nil
+}
+
+
+func (h *SyncListHandler) Reset() {
+    h.list.Lock()
+    log.Printf("SyncListHandler.Reset() of %d items, reset event.", h.list.Size())
+    h.list.Clear()
+    h.list.Unlock()
+}
+
+
+func (h *SyncListHandler) GetSyncList() *utils.SyncList {
+    return h.list
+}
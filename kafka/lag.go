@@ -0,0 +1,170 @@
+/** kafka.Consumer lag monitor
+  *
+  *  Samples the lag of the assigned partitions every site LagMs, from
+  *  the consumer position to the high watermark, and flags partitions
+  *  whose lag has grown over consecutive samples.
+  *
+  *  Copyright (c) 2023-2026 Timothy C. Arland <tcarland at gmail dot com>
+ **/
+package kafka
+
+import (
+    "log"
+    "sync"
+    "time"
+
+    "github.com/confluentinc/confluent-kafka-go/v2/kafka"
+)
+
+
+// LagFunc is called with each lag sample and the total lag
+type LagFunc func(lags []PartitionLag, total int64)
+
+const (
+    // lagGrowthSamples is the number of consecutive increases of the
+    // lag of a partition for it to be flagged as Growing.
+    lagGrowthSamples = 3
+    lagTimeoutMs     = 5000
+)
+
+
+// lagMonitor holds the last lag sample and the growth of each partition
+type lagMonitor struct {
+    lock    sync.Mutex
+    lags    []PartitionLag
+    growth  map[string]int
+    onLag   LagFunc
+}
+
+// -----------------------------------
+
+func newLagMonitor() *lagMonitor {
+    return &lagMonitor{ lags: make([]PartitionLag, 0), growth: make(map[string]int) }
+}
+
+
+/** update stores a new sample, flagging each partition whose lag grew
+  * in each of the last lagGrowthSamples samples. Partitions no longer
+  * in the sample are dropped.
+ **/
+func (l *lagMonitor) update(lags []PartitionLag) []PartitionLag {
+    l.lock.Lock()
+    defer l.lock.Unlock()
+
+    prev := make(map[string]int64, len(l.lags))
+    for _, pl := range l.lags {
+        prev[partitionKey(pl.Topic, pl.Partition)] = pl.Lag
+    }
+
+    growth := make(map[string]int, len(lags))
+    for i := range lags {
+        key := partitionKey(lags[i].Topic, lags[i].Partition)
+        if last, ok := prev[key]; ok && lags[i].Lag > last {
+            growth[key] = l.growth[key] + 1
+        }
+        lags[i].Growing = growth[key] >= lagGrowthSamples
+    }
+
+    l.lags   = lags
+    l.growth = growth
+    return lags
+}
+
+
+func (l *lagMonitor) last() []PartitionLag {
+    l.lock.Lock()
+    defer l.lock.Unlock()
+
+    return append([]PartitionLag(nil), l.lags...)
+}
+
+// -----------------------------------
+
+/** Lag returns the last lag sample of the assigned partitions and the
+  * total lag. Sampling runs every site LagMs while Consume() is running,
+  * and there is no sample when LagMs is 0.
+ **/
+func (c *Consumer) Lag() ([]PartitionLag, int64) {
+    lags := c.lagMon.last()
+    return lags, TotalLag(lags)
+}
+
+
+// OnLag sets a hook called with each lag sample, it should be set
+// before starting Consume().
+func (c *Consumer) OnLag(fn LagFunc) {
+    c.lagMon.onLag = fn
+}
+
+
+// lagLoop samples the lag every site LagMs until done is closed
+func (c *Consumer) lagLoop(done chan struct{}) {
+    interval := time.Duration(c.site.LagMs) * time.Millisecond
+    if interval <= 0 {
+        return
+    }
+
+    ticker := time.NewTicker(interval)
+    defer ticker.Stop()
+
+    for {
+        select {
+        case <- done:
+            return
+        case <- ticker.C:
+            lags, err := c.sampleLag()
+            if err != nil {
+                log.Printf("Consumer.lagLoop() '%s' error: %v", c.name, err)
+                c.sendError(err)
+                continue
+            }
+
+            lags = c.lagMon.update(lags)
+            for _, pl := range lags {
+                if pl.Growing {
+                    log.Printf("Consumer.lagLoop() '%s' lag of %s [%d] is growing: %d", c.name, pl.Topic, pl.Partition, pl.Lag)
+                }
+            }
+            if c.lagMon.onLag != nil {
+                c.lagMon.onLag(lags, TotalLag(lags))
+            }
+        }
+    }
+}
+
+
+// sampleLag returns the lag of the assigned partitions from their
+// current positions.
+func (c *Consumer) sampleLag() ([]PartitionLag, error) {
+    consumer, err := c.client()
+    if err != nil {
+        return nil, newError("Consumer.Lag", c.name, ErrState, err)
+    }
+
+    parts, err := consumer.Assignment()
+    if err != nil {
+        return nil, kafkaError("Consumer.Lag", c.name, err)
+    }
+    positions, err := consumer.Position(parts)
+    if err != nil {
+        return nil, kafkaError("Consumer.Lag", c.name, err)
+    }
+
+    high := make(map[string]int64, len(positions))
+    for i, tp := range positions {
+        _, hw, err := consumer.QueryWatermarkOffsets(*tp.Topic, tp.Partition, lagTimeoutMs)
+        if err != nil {
+            return nil, kafkaError("Consumer.Lag", *tp.Topic, err)
+        }
+        high[partitionKey(*tp.Topic, tp.Partition)] = hw
+
+        // no position until the first fetch, use the committed offset
+        if tp.Offset < 0 {
+            committed, err := consumer.Committed([]kafka.TopicPartition{ tp }, lagTimeoutMs)
+            if err == nil && len(committed) == 1 {
+                positions[i].Offset = committed[0].Offset
+            }
+        }
+    }
+    return partitionLag(positions, high), nil
+}
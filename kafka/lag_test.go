@@ -0,0 +1,71 @@
+package kafka
+
+import (
+    "errors"
+    "testing"
+
+    "github.com/tcarland/tca-kafka-go/config"
+)
+
+
+func lagSample(lags ...int64) []PartitionLag {
+    sample := make([]PartitionLag, 0, len(lags))
+    for i, lag := range lags {
+        sample = append(sample, PartitionLag{ Topic: "test", Partition: int32(i), Lag: lag })
+    }
+    return sample
+}
+
+
+func TestLagMonitor_Update(t *testing.T) {
+    t.Parallel()
+
+    testCases := []struct {
+        name       string
+        samples    [][]int64
+        exgrowing  []bool
+    }{
+        {"Steady", [][]int64{ {5}, {5}, {5}, {5} }, []bool{ false }},
+        {"Growing", [][]int64{ {1}, {2}, {3}, {4} }, []bool{ true }},
+        {"Not grown long enough", [][]int64{ {1}, {2}, {3} }, []bool{ false }},
+        {"Growth interrupted", [][]int64{ {1}, {2}, {3}, {3}, {4} }, []bool{ false }},
+        {"One partition growing", [][]int64{ {1, 9}, {2, 8}, {3, 9}, {4, 10} }, []bool{ true, false }},
+        {"Partition reassigned", [][]int64{ {1, 1}, {2, 2}, {3}, {4, 4} }, []bool{ true, false }},
+    }
+
+    for _, tc := range testCases {
+        t.Run(tc.name, func(t *testing.T) {
+            mon := newLagMonitor()
+
+            var lags []PartitionLag
+            for _, s := range tc.samples {
+                lags = mon.update(lagSample(s...))
+            }
+
+            for i, growing := range tc.exgrowing {
+                if lags[i].Growing != growing {
+                    t.Errorf("Expecting partition %d growing %v but got: %v", i, growing, lags[i])
+                }
+            }
+            if last := mon.last(); len(last) != len(lags) {
+                t.Errorf("Expecting the last sample of %d partitions but got: %v", len(lags), last)
+            }
+        })
+    }
+}
+
+
+func TestConsumer_Lag(t *testing.T) {
+    t.Parallel()
+
+    site := config.NewKafkaSite("localhost:9092", "test", "grp1")
+    site.LagMs = 1000
+    c := NewConsumer("test", site)
+
+    if lags, total := c.Lag(); len(lags) != 0 || total != 0 {
+        t.Errorf("Expecting no lag before consuming but got: %v %v", lags, total)
+    }
+    if _, err := c.sampleLag(); ! errors.Is(err, ErrState) {
+        t.Errorf("Expecting an ErrState error while not consuming but got: %v", err)
+    }
+}
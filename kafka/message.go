@@ -0,0 +1,114 @@
+/** kafka.Message
+  *
+  *  The message record delivered by the Consumer, carrying the
+  *  message metadata along with its value, and the Record sent
+  *  by the Producer.
+  *
+  *  Copyright (c) 2023-2026 Timothy C. Arland <tcarland at gmail dot com>
+ **/
+package kafka
+
+import (
+    "bytes"
+    "time"
+
+    "github.com/confluentinc/confluent-kafka-go/v2/kafka"
+)
+
+
+// Header is a message header key/value pair
+type Header = kafka.Header
+
+// PartitionAny lets the producer partitioner choose the partition
+const PartitionAny = kafka.PartitionAny
+
+
+/** Message is a consumed kafka record with its key, headers, timestamp
+  * and exact position given by topic, partition and offset.
+ **/
+type Message struct {
+    Topic      string
+    Partition  int32
+    Offset     int64
+    Key        []byte
+    Value      []byte
+    Headers    []Header
+    Timestamp  time.Time
+    epoch      uint64
+    gen        uint64
+}
+
+// -----------------------------------
+
+func newMessage(km *kafka.Message) *Message {
+    m := &Message{
+        Partition: km.TopicPartition.Partition,
+        Offset:    int64(km.TopicPartition.Offset),
+        Key:       km.Key,
+        Value:     km.Value,
+        Headers:   km.Headers,
+        Timestamp: km.Timestamp,
+    }
+    if km.TopicPartition.Topic != nil {
+        m.Topic = *km.TopicPartition.Topic
+    }
+    return m
+}
+
+
+// String returns the message value as a string
+func (m *Message) String() string {
+    return string(m.Value)
+}
+
+
+// GetHeader returns the value of the first header matching key
+func (m *Message) GetHeader(key string) ([]byte, bool) {
+    for _, h := range m.Headers {
+        if h.Key == key {
+            return h.Value, true
+        }
+    }
+    return nil, false
+}
+
+
+// Record returns a copy of the message as a Record to be sent by a
+// Producer, keeping the key, value and headers.
+func (m *Message) Record() *Record {
+    rec := NewRecord(m.Key, m.Value)
+    rec.Headers = append([]Header{}, m.Headers...)
+    return rec
+}
+
+
+func (m *Message) topicPartition() kafka.TopicPartition {
+    return kafka.TopicPartition{
+        Topic:     &m.Topic,
+        Partition: m.Partition,
+        Offset:    kafka.Offset(m.Offset),
+    }
+}
+
+// -----------------------------------
+
+/** Record is a message to be sent by the Producer. An empty Topic
+  * uses the Producer topic, and a zero Timestamp is set by the client.
+  * Records should be created with NewRecord() which sets the Partition
+  * to PartitionAny.
+ **/
+type Record struct {
+    Topic      string
+    Partition  int32
+    Key        []byte
+    Value      []byte
+    Headers    []Header
+    Timestamp  time.Time
+    buf       *bytes.Buffer
+    done       chan kafka.Event
+}
+
+
+func NewRecord(key []byte, value []byte) *Record {
+    return &Record{ Partition: PartitionAny, Key: key, Value: value }
+}
@@ -0,0 +1,68 @@
+package kafka
+
+import (
+    "testing"
+    "time"
+
+    "github.com/confluentinc/confluent-kafka-go/v2/kafka"
+)
+
+
+func TestNewMessage(t *testing.T) {
+    t.Parallel()
+
+    topic := "test"
+    ts    := time.UnixMilli(1700000000000)
+
+    testCases := []struct {
+        name     string
+        topic   *string
+        extopic  string
+    }{
+        {"With topic", &topic, "test"},
+        {"Missing topic", nil, ""},
+    }
+
+    for _, tc := range testCases {
+        t.Run(tc.name, func(t *testing.T) {
+            km := &kafka.Message{
+                TopicPartition: kafka.TopicPartition{ Topic: tc.topic, Partition: 3, Offset: 42 },
+                Key:            []byte("key"),
+                Value:          []byte("value"),
+                Headers:        []Header{ { Key: "h1", Value: []byte("v1") } },
+                Timestamp:      ts,
+            }
+
+            m := newMessage(km)
+            if m.Topic != tc.extopic || m.Partition != 3 || m.Offset != 42 {
+                t.Errorf("Expecting position %v [3] 42 but got: %v [%v] %v", tc.extopic, m.Topic, m.Partition, m.Offset)
+            }
+            if string(m.Key) != "key" || m.String() != "value" || ! m.Timestamp.Equal(ts) {
+                t.Errorf("Expecting the key, value and timestamp to be kept but got: %+v", m)
+            }
+            if v, ok := m.GetHeader("h1"); ! ok || string(v) != "v1" {
+                t.Errorf("Expecting header h1 of 'v1' but got: %v %v", string(v), ok)
+            }
+            if _, ok := m.GetHeader("h2"); ok {
+                t.Errorf("Expecting no header h2")
+            }
+        })
+    }
+}
+
+
+func TestNewRecord(t *testing.T) {
+    t.Parallel()
+
+    rec := NewRecord([]byte("key"), []byte("value"))
+
+    if rec.Partition != PartitionAny {
+        t.Errorf("Expecting partition PartitionAny but got: %v", rec.Partition)
+    }
+    if rec.Topic != "" || ! rec.Timestamp.IsZero() || rec.Headers != nil {
+        t.Errorf("Expecting no topic, timestamp or headers but got: %+v", rec)
+    }
+    if string(rec.Key) != "key" || string(rec.Value) != "value" {
+        t.Errorf("Expecting the key and value to be set but got: %+v", rec)
+    }
+}
@@ -0,0 +1,100 @@
+/** kafka client metrics
+  *
+  *  The Metrics interface receiving the events of Consumers and
+  *  Producers, such as the Prometheus Collector of the metrics package.
+  *  Clients have no metrics until one is set with SetMetrics().
+  *
+  *  Copyright (c) 2023-2026 Timothy C. Arland <tcarland at gmail dot com>
+ **/
+package kafka
+
+import (
+    "time"
+
+    "github.com/tcarland/tca-kafka-go/utils"
+
+    "github.com/confluentinc/confluent-kafka-go/v2/kafka"
+)
+
+
+/** Metrics receives client events labelled by the client name, being
+  * the Consumer name or the Producer topic. Calls are made from the
+  * client goroutines and must not block.
+ **/
+type Metrics interface {
+    // Consumed is called for each message read by a Consumer
+    Consumed(client string, topic string, bytes int)
+    // Handled is called for each call of the Handler or BatchHandler
+    Handled(client string, latency time.Duration, err error)
+    // Produced is called for each message delivered by a Producer
+    Produced(client string, topic string, bytes int)
+    // DeliveryFailed is called for each message that failed delivery
+    DeliveryFailed(client string, topic string)
+    // QueueFull is called when the producer queue is full
+    QueueFull(client string, topic string)
+    // Error is called for each error sent to the Errors() channel
+    Error(client string, err error)
+}
+
+
+type nopMetrics struct {}
+
+func (nopMetrics) Consumed(string, string, int)             {}
+func (nopMetrics) Handled(string, time.Duration, error)     {}
+func (nopMetrics) Produced(string, string, int)             {}
+func (nopMetrics) DeliveryFailed(string, string)            {}
+func (nopMetrics) QueueFull(string, string)                 {}
+func (nopMetrics) Error(string, error)                      {}
+
+// -----------------------------------
+
+// SetMetrics sets the Metrics of the Consumer and of its retry and
+// dead-letter Producer, and should be called before starting Consume().
+func (c *Consumer) SetMetrics(m Metrics) {
+    c.metrics = m
+    if c.out != nil {
+        c.out.SetMetrics(m)
+    }
+}
+
+
+// SetMetrics sets the Metrics of the Producer, and should be called
+// before starting Produce().
+func (p *Producer) SetMetrics(m Metrics) {
+    p.metrics = m
+}
+
+
+// GetBufferPool returns the pool of buffers used by SendMessage()
+func (p *Producer) GetBufferPool() *utils.BufferPool {
+    return p.buffers
+}
+
+
+// sendError sends a Consumer error to the Errors() channel and Metrics
+func (c *Consumer) sendError(err error) {
+    c.metrics.Error(c.name, err)
+    sendError(c.errc, err)
+}
+
+
+// sendError sends a Producer error to the Errors() channel and Metrics
+func (p *Producer) sendError(err error) {
+    p.metrics.Error(p.topic, err)
+    sendError(p.errc, err)
+}
+
+
+// delivery passes a delivery result to Metrics
+func (p *Producer) delivery(m *kafka.Message) {
+    topic := p.topic
+    if m.TopicPartition.Topic != nil {
+        topic = *m.TopicPartition.Topic
+    }
+
+    if m.TopicPartition.Error != nil {
+        p.metrics.DeliveryFailed(p.topic, topic)
+        return
+    }
+    p.metrics.Produced(p.topic, topic, len(m.Value))
+}
@@ -0,0 +1,92 @@
+package kafka
+
+import (
+    "context"
+    "errors"
+    "sync"
+    "testing"
+    "time"
+
+    "github.com/tcarland/tca-kafka-go/config"
+)
+
+
+// testMetrics counts the Metrics events by name
+type testMetrics struct {
+    lock    sync.Mutex
+    counts  map[string]int
+}
+
+func newTestMetrics() *testMetrics {
+    return &testMetrics{ counts: make(map[string]int) }
+}
+
+func (m *testMetrics) add(event string) {
+    m.lock.Lock()
+    defer m.lock.Unlock()
+    m.counts[event]++
+}
+
+func (m *testMetrics) count(event string) int {
+    m.lock.Lock()
+    defer m.lock.Unlock()
+    return m.counts[event]
+}
+
+func (m *testMetrics) Consumed(string, string, int)         { m.add("consumed") }
+func (m *testMetrics) Handled(string, time.Duration, error) { m.add("handled") }
+func (m *testMetrics) Produced(string, string, int)         { m.add("produced") }
+func (m *testMetrics) DeliveryFailed(string, string)        { m.add("failed") }
+func (m *testMetrics) QueueFull(string, string)             { m.add("queuefull") }
+func (m *testMetrics) Error(string, error)                  { m.add("error") }
+
+
+func TestConsumer_Metrics(t *testing.T) {
+    t.Parallel()
+
+    site := config.NewKafkaSite("localhost:9092", "test", "grp1")
+    site.MaxRetries = 1
+    site.RetryMs    = 1
+    site.DLQTopic   = "test.dlq"
+
+    metrics := newTestMetrics()
+    c := NewConsumer("test", site)
+    c.SetMetrics(metrics)
+    c.SetHandler(HandlerFunc(func(ctx context.Context, m *Message) error {
+        return errors.New("failed")
+    }))
+
+    if c.out == nil || c.out.metrics != metrics {
+        t.Fatalf("Expecting the metrics to be set on the dead-letter producer")
+    }
+
+    ctx, cancel := context.WithCancel(context.Background())
+    cancel()
+    c.dispatch(ctx, &Message{ Topic: "test" })
+
+    if n := metrics.count("handled"); n != 1 {
+        t.Errorf("Expecting 1 handler call but got: %v", n)
+    }
+}
+
+
+func TestProducer_Metrics(t *testing.T) {
+    t.Parallel()
+
+    site := config.NewKafkaSite("localhost:9092", "test", "")
+    site.TxnId = "txn-1"
+
+    metrics := newTestMetrics()
+    p := NewSiteProducer(site)
+    p.SetMetrics(metrics)
+
+    // a transactional producer that is not running fails the send
+    p.SendRecord(NewRecord(nil, []byte("value")))
+
+    if metrics.count("failed") != 1 || metrics.count("error") != 1 {
+        t.Errorf("Expecting a delivery failure and an error but got: %v", metrics.counts)
+    }
+    if p.GetBufferPool() == nil {
+        t.Errorf("Expecting the producer buffer pool")
+    }
+}
@@ -0,0 +1,287 @@
+/** kafka.Pipeline
+  *
+  *  An exactly-once consume-transform-produce pipeline, reading from an
+  *  input site and writing the transformed records to an output site,
+  *  with each batch written and its input offsets committed together
+  *  in a producer transaction.
+  *
+  *  Copyright (c) 2023-2026 Timothy C. Arland <tcarland at gmail dot com>
+ **/
+package kafka
+
+import (
+    "context"
+    "errors"
+    "log"
+    "sync"
+    "sync/atomic"
+    "time"
+
+    "github.com/tcarland/tca-kafka-go/config"
+)
+
+
+// TransformFunc maps a consumed message to zero, one or many records
+// to produce. An empty Record topic uses the output site topic.
+type TransformFunc func(ctx context.Context, msg *Message) ([]*Record, error)
+
+var (
+    errNoTxnId = errors.New("output site requires a transactionalid")
+    errLost    = errors.New("partitions lost during the transaction")
+)
+
+const txnAbortTimeout = 30 * time.Second
+
+
+/** Pipeline runs a Consumer of the input site in batch mode and a
+  * transactional Producer of the output site. Only messages of the
+  * partitions currently assigned are processed, and messages read
+  * before a partition was last assigned are dropped, as they are read
+  * again from the committed offsets.
+ **/
+type Pipeline struct {
+    name       string
+    consumer  *Consumer
+    producer  *Producer
+    transform  TransformFunc
+    lock       sync.Mutex
+    owned      map[string]uint64
+    lost       atomic.Bool
+    cancel     context.CancelCauseFunc
+}
+
+// -----------------------------------
+
+func NewPipeline(name string, in *config.KafkaSite, out *config.KafkaSite, fn TransformFunc) *Pipeline {
+    return new(Pipeline).InitPipeline(name, in, out, fn)
+}
+
+
+/** InitPipeline sets up the pipeline clients. The input site is used
+  * with the 'read_committed' isolation level, and the output site must
+  * set a TransactionalId unique to each pipeline instance.
+ **/
+func (p *Pipeline) InitPipeline(name string, in *config.KafkaSite, out *config.KafkaSite, fn TransformFunc) *Pipeline {
+    site := *in
+    site.Properties = make(map[string]string)
+    for k, v := range in.Properties {
+        site.Properties[k] = v
+    }
+    site.Properties["isolation.level"] = "read_committed"
+
+    p.name      = name
+    p.consumer  = NewConsumer(name, &site)
+    p.producer  = NewSiteProducer(out)
+    p.transform = fn
+    p.owned     = make(map[string]uint64)
+
+    p.consumer.txnOffsets = true
+    p.consumer.SetBatchHandler(BatchHandlerFunc(p.handleBatch))
+    p.consumer.OnAssigned(p.assigned)
+    p.consumer.OnRevoked(p.revoked)
+    p.consumer.OnLost(p.partitionsLost)
+    p.producer.errc = p.consumer.errc
+    return p
+}
+
+
+/** Run the pipeline until the context is done or a fatal error, such
+  * as the producer being fenced by a newer instance with the same
+  * TransactionalId, which is returned. Returns an ErrConfig error if
+  * either client cannot be created.
+ **/
+func (p *Pipeline) Run(ctx context.Context) error {
+    if ! p.producer.transactional() {
+        return newError("Pipeline.Run", p.name, ErrConfig, errNoTxnId)
+    }
+
+    ctx, cancel := context.WithCancelCause(ctx)
+    defer cancel(nil)
+    p.cancel = cancel
+
+    if err := p.producer.InitTransactions(ctx); err != nil {
+        return err
+    }
+
+    wg := sync.WaitGroup{}
+    wg.Add(2)
+    go func() {
+        defer wg.Done()
+        if err := p.producer.Produce(ctx); err != nil {
+            p.producer.sendError(err)
+        }
+    }()
+    go func() {
+        defer wg.Done()
+        p.consumer.Process(ctx)
+    }()
+
+    log.Printf("kafka.Pipeline.Run() run '%s'", p.name)
+    err := p.consumer.Consume(ctx)
+    cancel(nil)
+    wg.Wait()
+
+    if cause := context.Cause(ctx); cause != nil && ! errors.Is(cause, context.Canceled) {
+        return cause
+    }
+    return err
+}
+
+
+/** handleBatch processes the batch in a transaction. Transaction
+  * errors are aborted and the batch retried every site RetryMs until
+  * it commits, while a transform error aborts the transaction and is
+  * returned, to be retried or dead-lettered by the Consumer.
+ **/
+func (p *Pipeline) handleBatch(ctx context.Context, msgs []*Message) error {
+    backoff := time.Duration(p.consumer.site.RetryMs) * time.Millisecond
+
+    for {
+        err := p.runTxn(ctx, msgs)
+
+        switch {
+        case err == nil:
+            return nil
+        case errors.Is(err, ErrFatal):
+            log.Printf("Pipeline.handleBatch() '%s' fatal error: %v", p.name, err)
+            p.cancel(err)
+            return err
+        case errors.Is(err, ErrHandler) || ctx.Err() != nil:
+            return err
+        }
+
+        log.Printf("Pipeline.handleBatch() '%s' transaction aborted, retrying: %v", p.name, err)
+        select {
+        case <- ctx.Done():
+            return errors.Join(err, ctx.Err())
+        case <- time.After(backoff):
+        }
+    }
+}
+
+
+func (p *Pipeline) Errors() <-chan error {
+    return p.consumer.Errors()
+}
+
+
+func (p *Pipeline) GetConsumer() *Consumer {
+    return p.consumer
+}
+
+
+func (p *Pipeline) GetProducer() *Producer {
+    return p.producer
+}
+
+// -----------------------------------
+
+/** runTxn produces the transformed batch and commits it with the batch
+  * offsets in one transaction, holding the pipeline lock so that a
+  * revoke waits for the transaction to finish.
+ **/
+func (p *Pipeline) runTxn(ctx context.Context, msgs []*Message) error {
+    p.lock.Lock()
+    defer p.lock.Unlock()
+
+    batch := p.filter(msgs)
+    if len(batch) == 0 {
+        return nil
+    }
+
+    if err := p.producer.BeginTxn(); err != nil {
+        return err
+    }
+
+    for _, m := range batch {
+        recs, err := p.transform(ctx, m)
+        if err != nil {
+            return p.abort(newError("Pipeline.Transform", p.name, ErrHandler, err))
+        }
+        for _, rec := range recs {
+            p.producer.SendRecord(rec)
+        }
+    }
+
+    if p.lost.Load() {
+        return p.abort(errLost)
+    }
+
+    group, err := p.consumer.GroupMetadata()
+    if err != nil {
+        return p.abort(err)
+    }
+    if err := p.producer.SendOffsetsToTxn(ctx, NextOffsets(batch), group); err != nil {
+        return p.abort(err)
+    }
+    if p.lost.Load() {
+        return p.abort(errLost)
+    }
+    if err := p.producer.CommitTxn(ctx); err != nil {
+        return p.abort(err)
+    }
+    return nil
+}
+
+
+// abort aborts the open transaction, returning the error that caused it
+func (p *Pipeline) abort(err error) error {
+    if errors.Is(err, ErrFatal) {
+        return err
+    }
+
+    ctx, cancel := context.WithTimeout(context.Background(), txnAbortTimeout)
+    defer cancel()
+
+    if aerr := p.producer.AbortTxn(ctx); aerr != nil {
+        log.Printf("Pipeline.abort() '%s' error: %v", p.name, aerr)
+        return errors.Join(err, aerr)
+    }
+    return err
+}
+
+
+// filter returns the messages of owned partitions read since assigned
+func (p *Pipeline) filter(msgs []*Message) []*Message {
+    batch := make([]*Message, 0, len(msgs))
+
+    for _, m := range msgs {
+        epoch, ok := p.owned[partitionKey(m.Topic, m.Partition)]
+        if ok && m.epoch >= epoch {
+            batch = append(batch, m)
+        }
+    }
+    return batch
+}
+
+// -----------------------------------
+
+func (p *Pipeline) assigned(parts []TopicPartition) {
+    p.lock.Lock()
+    defer p.lock.Unlock()
+
+    for _, tp := range parts {
+        p.owned[partitionKey(*tp.Topic, tp.Partition)] = p.consumer.epoch
+    }
+}
+
+
+// revoked waits for a running transaction to commit before the
+// partitions are given up.
+func (p *Pipeline) revoked(parts []TopicPartition) {
+    p.lock.Lock()
+    defer p.lock.Unlock()
+
+    for _, tp := range parts {
+        delete(p.owned, partitionKey(*tp.Topic, tp.Partition))
+    }
+}
+
+
+// partitionsLost aborts a running transaction, as its offsets can no
+// longer be committed.
+func (p *Pipeline) partitionsLost(parts []TopicPartition) {
+    p.lost.Store(true)
+    p.revoked(parts)
+    p.lost.Store(false)
+}
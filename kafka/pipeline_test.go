@@ -0,0 +1,69 @@
+package kafka
+
+import (
+    "context"
+    "errors"
+    "testing"
+
+    "github.com/tcarland/tca-kafka-go/config"
+)
+
+
+func TestPipeline_Filter(t *testing.T) {
+    t.Parallel()
+
+    in  := config.NewKafkaSite("localhost:9092", "in", "grp1")
+    out := config.NewKafkaSite("localhost:9092", "out", "")
+    p   := NewPipeline("test", in, out, nil)
+
+    topic := "in"
+    p.consumer.epoch = 2
+    p.assigned([]TopicPartition{ { Topic: &topic, Partition: 0 }, { Topic: &topic, Partition: 1 } })
+    p.consumer.epoch = 3
+    p.revoked([]TopicPartition{ { Topic: &topic, Partition: 1 } })
+
+    testCases := []struct {
+        name   string
+        msg   *Message
+        exok   bool
+    }{
+        {"Owned partition", &Message{ Topic: "in", Partition: 0, epoch: 2 }, true},
+        {"Read before assignment", &Message{ Topic: "in", Partition: 0, epoch: 1 }, false},
+        {"Revoked partition", &Message{ Topic: "in", Partition: 1, epoch: 2 }, false},
+        {"Unassigned partition", &Message{ Topic: "in", Partition: 2, epoch: 3 }, false},
+    }
+
+    for _, tc := range testCases {
+        t.Run(tc.name, func(t *testing.T) {
+            p.lock.Lock()
+            batch := p.filter([]*Message{ tc.msg })
+            p.lock.Unlock()
+
+            if (len(batch) == 1) != tc.exok {
+                t.Errorf("Expecting the message kept %v but got: %v", tc.exok, batch)
+            }
+        })
+    }
+}
+
+
+func TestPipeline_Config(t *testing.T) {
+    t.Parallel()
+
+    in  := config.NewKafkaSite("localhost:9092", "in", "grp1")
+    out := config.NewKafkaSite("localhost:9092", "out", "")
+    p   := NewPipeline("test", in, out, nil)
+
+    if v := p.GetConsumer().GetSiteConfig().Properties["isolation.level"]; v != "read_committed" {
+        t.Errorf("Expecting the read_committed isolation level but got: '%v'", v)
+    }
+    if _, ok := in.Properties["isolation.level"]; ok {
+        t.Errorf("Expecting the input site properties to be unchanged")
+    }
+    if ! p.GetConsumer().manualCommit() {
+        t.Errorf("Expecting the consumer to leave offsets to the transactions")
+    }
+    if err := p.Run(context.Background()); ! errors.Is(err, ErrConfig) {
+        t.Errorf("Expecting an ErrConfig error without a transactionalid but got: %v", err)
+    }
+}
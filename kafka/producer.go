@@ -11,9 +11,9 @@
 package kafka
 
 import (
-    "bytes"
     "context"
     "log"
+    "sync"
 
     "github.com/tcarland/tca-kafka-go/config"
     "github.com/tcarland/tca-kafka-go/utils"
@@ -29,9 +29,15 @@ import (
  **/
 type Producer struct {
     topic     string
-    brokers   string
+    site     *config.KafkaSite
     buffers  *utils.BufferPool
-    bpc       chan *bytes.Buffer
+    bpc       chan *Record
+    producer *kafka.Producer
+    lock      sync.Mutex
+    headers   []Header
+    reports   chan *DeliveryReport
+    metrics   Metrics
+    errc      chan error
     active    bool
 }
 
@@ -42,84 +48,185 @@ func NewProducer(brokers string, topic string) *Producer {
 }
 
 
+// NewSiteProducer creates a Producer for the site brokers and topic,
+// applying the site client properties.
+func NewSiteProducer(site *config.KafkaSite) *Producer {
+    return new(Producer).InitSiteProducer(site)
+}
+
+
 func (p *Producer) InitProducer(brokers string, topic string) *Producer {
-    p.topic   = topic
-    p.brokers = brokers
+    return p.InitSiteProducer(config.NewKafkaSite(brokers, topic, ""))
+}
+
+
+func (p *Producer) InitSiteProducer(site *config.KafkaSite) *Producer {
+    p.topic   = site.Topic
+    p.site    = site
     p.buffers = utils.NewBufferPool(100)
-    p.bpc     = make(chan *bytes.Buffer)
+    p.bpc     = make(chan *Record)
+    p.errc    = make(chan error, 100)
+    p.metrics = nopMetrics{}
     p.active  = false
     return p
 }
 
 // -----------------------------------
 
-// Kafka Producer to be ran as a goroutine
-func (p *Producer) Produce(ctx context.Context) {
-    producer, err := kafka.NewProducer(&kafka.ConfigMap{"bootstrap.servers": p.brokers})
+/** Kafka Producer to be ran as a goroutine. Returns an ErrConfig error
+  * if the client cannot be created. Runtime and delivery errors are
+  * sent to the Errors() channel.
+ **/
+func (p *Producer) Produce(ctx context.Context) error {
+    producer, err := p.start(ctx)
+    if err != nil {
+        return err
+    }
+
+    log.Printf("kafka.Producer.Produce() run '%s'", p.topic)
+    p.active = true
+
+    for p.active {
+        select {
+        case <- ctx.Done():
+            p.active = false
+        case rec := <-p.bpc:
+            p.produce(producer, rec)
+        }
+    }
+
+    for producer.Flush(10000) > 0 {
+        log.Println("Producer Flush() ...")
+    }
+
+    log.Printf("Producer finished for '%s'", p.topic)
+    p.lock.Lock()
+    p.producer = nil
+    p.lock.Unlock()
+    producer.Close()
+    return nil
+}
+
+
+/** start creates the client and its events goroutine, once, and
+  * initializes transactions when the site has a TransactionalId.
+  * Returns the running client if already started.
+ **/
+func (p *Producer) start(ctx context.Context) (*kafka.Producer, error) {
+    p.lock.Lock()
+    defer p.lock.Unlock()
+
+    if p.producer != nil {
+        return p.producer, nil
+    }
+
+    p.site.SetDefaults()
+    if err := p.site.Validate(); err != nil {
+        return nil, newError("Producer.Produce", p.topic, ErrConfig, err)
+    }
+
+    cfg, err := newConfigMap(p.site, config.ProducerClient, nil, p.clientConfig())
 
     if err != nil {
-        log.Fatal(err.Error())
-        return
+        return nil, newError("Producer.Produce", p.topic, ErrConfig, err)
+    }
+
+    producer, err := kafka.NewProducer(cfg)
+
+    if err != nil {
+        return nil, newError("Producer.Produce", p.topic, ErrConfig, err)
     }
 
     go func() {
         for e := range producer.Events() {
             switch ev := e.(type) {
             case *kafka.Message:
-                m := ev
-                if m.TopicPartition.Error != nil {
-                    log.Printf("Delivery failed: %v\n", m.TopicPartition.Error)
-                } else {
-                    log.Printf("Delivered message to topic %s [%d] at offset %v\n",
-                        *m.TopicPartition.Topic, m.TopicPartition.Partition, m.TopicPartition.Offset)
-                }
+                p.delivered(ev)
             case kafka.Error:
                 log.Printf("Producer Events Error: %v\n", ev)
+                p.sendError(kafkaError("Producer.Events", p.topic, ev))
             default:
                 log.Printf("Producer Ignored event: %s\n", ev)
             }
         }
     }()
 
-    log.Printf("kafka.Producer.Produce() run '%s'", p.topic)
-    p.active = true
+    if p.transactional() {
+        if err := producer.InitTransactions(ctx); err != nil {
+            producer.Close()
+            return nil, kafkaError("Producer.InitTransactions", p.topic, err)
+        }
+    }
 
-    for p.active {
-        select {
-        case <- ctx.Done():
-            p.active = false
-            continue
-        default:
-            if ! p.active {
-                break
-            }
+    p.producer = producer
+    return producer, nil
+}
 
-            b := <-p.bpc
-
-            err := producer.Produce(&kafka.Message {
-                TopicPartition:   kafka.TopicPartition{Topic: &p.topic, Partition: kafka.PartitionAny},
-                Value:            b.Bytes(),
-                Headers:        []kafka.Header{{Key: "ipflow-dashboard", Value: []byte(p.topic)}}, 
-            }, nil)
-        
-            if err == nil {
-                log.Printf("Produce() event: '%s' ", b.String())
-            } else if err.(kafka.Error).Code() == kafka.ErrQueueFull {
-                log.Println("Producer queue full")            
-            } else if err.(kafka.Error).Code() != kafka.ErrTimedOut { 
-                log.Printf("Producer error: %v", err)
-            }
 
-            p.buffers.Put(b)
+// produce hands a Record to the client
+func (p *Producer) produce(producer *kafka.Producer, rec *Record) {
+    msg := p.newMessage(rec)
+    err := producer.Produce(msg, rec.done)
+
+    if err == nil {
+        log.Printf("Produce() event: '%s' ", rec.Value)
+    } else {
+        if err.(kafka.Error).Code() == kafka.ErrQueueFull {
+            log.Println("Producer queue full")
+            p.metrics.QueueFull(p.topic, *msg.TopicPartition.Topic)
+        } else {
+            log.Printf("Producer error: %v", err)
         }
+        p.produceFailed(rec, err)
     }
 
-    for producer.Flush(10000) > 0 {
-        log.Println("Producer Flush() ...")
+    // reported records keep their buffer
+    if rec.buf != nil && p.reports == nil {
+        p.buffers.Put(rec.buf)
     }
+}
 
-    log.Printf("Producer finished for '%s'", p.topic)
-    producer.Close()
+
+/** send queues a Record to the Produce() goroutine, or hands it
+  * directly to the client of a transactional Producer so that it is
+  * part of the open transaction when the send returns.
+ **/
+func (p *Producer) send(rec *Record) {
+    if ! p.transactional() {
+        p.bpc <- rec
+        return
+    }
+
+    producer, err := p.client()
+    if err != nil {
+        p.produceFailed(rec, kafka.NewError(kafka.ErrState, err.Error(), false))
+        return
+    }
+    p.produce(producer, rec)
+}
+
+// -----------------------------------
+
+// newMessage converts a Record, adding the Producer headers
+func (p *Producer) newMessage(rec *Record) *kafka.Message {
+    topic := rec.Topic
+    if topic == "" {
+        topic = p.topic
+    }
+
+    headers := rec.Headers
+    if len(p.headers) > 0 {
+        headers = append(append([]Header{}, p.headers...), rec.Headers...)
+    }
+
+    return &kafka.Message{
+        TopicPartition: kafka.TopicPartition{Topic: &topic, Partition: rec.Partition},
+        Key:            rec.Key,
+        Value:          rec.Value,
+        Headers:        headers,
+        Timestamp:      rec.Timestamp,
+        Opaque:         rec,
+    }
 }
 
 // -----------------------------------
@@ -127,7 +234,28 @@ func (p *Producer) Produce(ctx context.Context) {
 func (p *Producer) SendMessage(msg string) {
     b := p.buffers.Get()
     b.Write([]byte(msg))
-    p.bpc <- b
+
+    rec    := NewRecord(nil, b.Bytes())
+    rec.buf = b
+    p.send(rec)
+}
+
+
+// SendRecord queues a Record with its key, headers, partition and
+// timestamp to the Produce() goroutine.
+func (p *Producer) SendRecord(rec *Record) {
+    p.send(rec)
+}
+
+
+// SetHeaders sets the headers added to every message sent
+func (p *Producer) SetHeaders(headers ...Header) {
+    p.headers = headers
+}
+
+
+func (p *Producer) AddHeader(key string, value []byte) {
+    p.headers = append(p.headers, Header{ Key: key, Value: value })
 }
 
 
@@ -135,35 +263,34 @@ func (p *Producer) IsActive() bool {
     return p.active
 }
 
-// -----------------------------------
 
-func (p *Producer) CreateTopic(numParts int, replFactor int) {
-    admin, err := kafka.NewAdminClient(&kafka.ConfigMap{"bootstrap.servers": p.brokers})
+func (p *Producer) GetSiteConfig() *config.KafkaSite {
+    return p.site
+}
 
-    if err != nil {
-        log.Fatal(err.Error())
-    }
 
-    ctx, cancel := context.WithCancel(context.Background())
-    defer cancel()
-
-    results, err := admin.CreateTopics(
-        ctx,
-        []kafka.TopicSpecification{ {
-            Topic:             p.topic,
-            NumPartitions:     numParts,
-            ReplicationFactor: replFactor,
-        } })
-        
-    if err != nil {
-        log.Fatal(err.Error())
-    }
+// Errors returns the channel of runtime and delivery errors. The channel
+// is never closed and errors are dropped when it is full.
+func (p *Producer) Errors() <-chan error {
+    return p.errc
+}
 
-    for _, result := range results {
-        log.Printf("Producer.CreateTopic '%s'\n", result)
-    }
+// -----------------------------------
 
-    admin.Close()
+// CreateTopic creates the producer topic with an Admin client of the
+// site, returning an ErrConfig error if the client cannot be created or
+// the replication factor exceeds the cluster size, or an ErrBroker error
+// on failure.
+func (p *Producer) CreateTopic(numParts int, replFactor int) error {
+    admin := NewAdmin(p.site)
+    defer admin.Close()
+
+    _, err := admin.CreateTopics(context.Background(), TopicSpec{
+        Topic:      p.topic,
+        Partitions: numParts,
+        Replicas:   replFactor,
+    })
+    return err
 }
 
 func (p *Producer) Version() string{
@@ -0,0 +1,56 @@
+package kafka
+
+import (
+    "testing"
+    "time"
+)
+
+
+func TestProducer_NewMessage(t *testing.T) {
+    t.Parallel()
+
+    testCases := []struct {
+        name       string
+        topic      string
+        headers    []Header
+        extopic    string
+        exheaders  []string
+    }{
+        {"Producer topic", "", nil, "default", []string{"rec"}},
+        {"Record topic", "other", nil, "other", []string{"rec"}},
+        {"Producer headers first", "", []Header{ { Key: "app" }, { Key: "host" } }, "default", []string{"app", "host", "rec"}},
+    }
+
+    for _, tc := range testCases {
+        t.Run(tc.name, func(t *testing.T) {
+            p := NewProducer("localhost:9092", "default")
+            p.SetHeaders(tc.headers...)
+
+            rec := NewRecord([]byte("key"), []byte("value"))
+            rec.Topic     = tc.topic
+            rec.Partition = 2
+            rec.Timestamp = time.UnixMilli(1700000000000)
+            rec.Headers   = []Header{ { Key: "rec" } }
+
+            msg := p.newMessage(rec)
+            if *msg.TopicPartition.Topic != tc.extopic || msg.TopicPartition.Partition != 2 {
+                t.Errorf("Expecting %v [2] but got: %v [%v]", tc.extopic, *msg.TopicPartition.Topic, msg.TopicPartition.Partition)
+            }
+            if msg.Opaque != rec || ! msg.Timestamp.Equal(rec.Timestamp) || string(msg.Key) != "key" {
+                t.Errorf("Expecting the record fields to be kept but got: %v", msg)
+            }
+
+            if len(msg.Headers) != len(tc.exheaders) {
+                t.Fatalf("Expecting headers %v but got: %v", tc.exheaders, msg.Headers)
+            }
+            for i, key := range tc.exheaders {
+                if msg.Headers[i].Key != key {
+                    t.Errorf("Expecting headers %v but got: %v", tc.exheaders, msg.Headers)
+                }
+            }
+            if len(rec.Headers) != 1 {
+                t.Errorf("Expecting the record headers to be unchanged but got: %v", rec.Headers)
+            }
+        })
+    }
+}
@@ -0,0 +1,118 @@
+/** kafka.Consumer rebalance events
+  *
+  *  Handles partition assignment and revocation for both the eager and
+  *  the cooperative (incremental) rebalance protocols, applying start
+  *  offsets, calling the application hooks and committing pending
+  *  offsets on revoke.
+  *
+  *  Copyright (c) 2023-2026 Timothy C. Arland <tcarland at gmail dot com>
+ **/
+package kafka
+
+import (
+    "log"
+
+    "github.com/confluentinc/confluent-kafka-go/v2/kafka"
+)
+
+
+// TopicPartition identifies a topic partition and an offset
+type TopicPartition = kafka.TopicPartition
+
+// PartitionsFunc is a rebalance hook given the affected partitions
+type PartitionsFunc func(partitions []TopicPartition)
+
+// -----------------------------------
+
+// OnAssigned sets the hook called after partitions are assigned
+func (c *Consumer) OnAssigned(fn PartitionsFunc) {
+    c.onAssigned = fn
+}
+
+
+// OnRevoked sets the hook called when partitions are revoked, after
+// pending offsets are committed and before they are unassigned.
+func (c *Consumer) OnRevoked(fn PartitionsFunc) {
+    c.onRevoked = fn
+}
+
+
+// OnLost sets the hook called when the assignment is lost, such as on
+// a session timeout, in which case offsets can no longer be committed.
+func (c *Consumer) OnLost(fn PartitionsFunc) {
+    c.onLost = fn
+}
+
+// -----------------------------------
+
+/** rebalance is the RebalanceCb of the subscription. Partitions are
+  * assigned or unassigned incrementally when the cooperative-sticky
+  * assignor is configured through the partition.assignment.strategy
+  * site property.
+ **/
+func (c *Consumer) rebalance(consumer *kafka.Consumer, ev kafka.Event) error {
+    cooperative := consumer.GetRebalanceProtocol() == "COOPERATIVE"
+
+    switch e := ev.(type) {
+    case kafka.AssignedPartitions:
+        c.epoch++
+        log.Printf("Consumer.rebalance() '%s' assigned %d partitions", c.name, len(e.Partitions))
+
+        c.tracker.remove(e.Partitions)
+        if err := c.startOffsets(consumer, e.Partitions); err != nil {
+            log.Printf("Consumer.rebalance() '%s' start offsets error: %v", c.name, err)
+            c.sendError(kafkaError("Consumer.rebalance", c.name, err))
+        }
+
+        var err error
+        if cooperative {
+            err = consumer.IncrementalAssign(e.Partitions)
+        } else {
+            err = consumer.Assign(e.Partitions)
+        }
+        if err != nil {
+            c.sendError(kafkaError("Consumer.rebalance", c.name, err))
+            return err
+        }
+
+        if err := c.pauseAssigned(consumer, e.Partitions); err != nil {
+            c.sendError(kafkaError("Consumer.rebalance", c.name, err))
+        }
+
+        if c.onAssigned != nil {
+            c.onAssigned(e.Partitions)
+        }
+
+    case kafka.RevokedPartitions:
+        c.epoch++
+        if consumer.AssignmentLost() {
+            log.Printf("Consumer.rebalance() '%s' lost %d partitions", c.name, len(e.Partitions))
+            if c.onLost != nil {
+                c.onLost(e.Partitions)
+            }
+        } else {
+            log.Printf("Consumer.rebalance() '%s' revoked %d partitions", c.name, len(e.Partitions))
+            if c.manualCommit() {
+                c.Commit()
+            }
+            if c.onRevoked != nil {
+                c.onRevoked(e.Partitions)
+            }
+        }
+        c.tracker.remove(e.Partitions)
+        c.rewinds.drop(e.Partitions)
+
+        var err error
+        if cooperative {
+            err = consumer.IncrementalUnassign(e.Partitions)
+        } else {
+            err = consumer.Unassign()
+        }
+        if err != nil {
+            c.sendError(kafkaError("Consumer.rebalance", c.name, err))
+            return err
+        }
+    }
+
+    return nil
+}
@@ -0,0 +1,73 @@
+package kafka
+
+import (
+    "testing"
+    "time"
+
+    "github.com/tcarland/tca-kafka-go/config"
+
+    "github.com/confluentinc/confluent-kafka-go/v2/kafka"
+)
+
+
+// newTestClient returns a client that is never connected to a broker
+func newTestClient(t *testing.T) *kafka.Consumer {
+    client, err := kafka.NewConsumer(&kafka.ConfigMap{
+        "bootstrap.servers": "localhost:9092",
+        "group.id":          "grp1",
+    })
+    if err != nil {
+        t.Fatalf("Unexpected error creating the client: %v", err)
+    }
+    t.Cleanup(func() { client.Close() })
+    return client
+}
+
+
+func TestConsumer_Rebalance(t *testing.T) {
+    t.Parallel()
+
+    site := config.NewKafkaSite("localhost:9092", "test", "grp1")
+    site.ManualCommit = true
+
+    calls := make(map[string]int)
+    c := NewConsumer("test", site)
+    c.OnAssigned(func(parts []TopicPartition) { calls["assigned"] += len(parts) })
+    c.OnRevoked(func(parts []TopicPartition) { calls["revoked"] += len(parts) })
+    c.OnLost(func(parts []TopicPartition) { calls["lost"] += len(parts) })
+
+    client := newTestClient(t)
+    topic  := "test"
+    parts  := []TopicPartition{ { Topic: &topic, Partition: 0 }, { Topic: &topic, Partition: 1 } }
+
+    if err := c.rebalance(client, kafka.AssignedPartitions{ Partitions: parts }); err != nil {
+        t.Fatalf("Unexpected error assigning partitions: %v", err)
+    }
+    if calls["assigned"] != 2 || c.epoch != 1 || c.tracker.generation("test", 0) != 1 {
+        t.Errorf("Expecting the assign hook, epoch and generation to advance but got: %v %v", calls, c.epoch)
+    }
+
+    c.rewinds.add(TopicPartition{ Topic: &topic, Partition: 1, Offset: 5 })
+
+    // revoked as the client closes, with no client left to commit with
+    done := make(chan error, 1)
+    go func() {
+        done <- c.rebalance(client, kafka.RevokedPartitions{ Partitions: parts })
+    }()
+
+    select {
+    case err := <-done:
+        if err != nil {
+            t.Errorf("Unexpected error revoking partitions: %v", err)
+        }
+    case <-time.After(5 * time.Second):
+        t.Fatalf("Timed out revoking partitions")
+    }
+
+    if calls["revoked"] != 2 || calls["lost"] != 0 || c.epoch != 2 {
+        t.Errorf("Expecting only the revoke hook and the epoch to advance but got: %v %v", calls, c.epoch)
+    }
+    if c.tracker.generation("test", 1) != 2 || c.rewinds.pending("test", 1) {
+        t.Errorf("Expecting the revoked partitions to advance with no pending rewind")
+    }
+}
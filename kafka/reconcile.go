@@ -0,0 +1,434 @@
+/** kafka.Reconciler
+  *
+  *  Declarative topic management from a set of KafkaSites. The topics
+  *  of each site, with its Partitions, Replicas and TopicConfigs, are
+  *  compared against their cluster to produce a Plan of the changes
+  *  needed, which may be reviewed as a dry-run and then applied.
+  *
+  *  Copyright (c) 2023-2026 Timothy C. Arland <tcarland at gmail dot com>
+ **/
+package kafka
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "log"
+    "sort"
+    "strings"
+
+    "github.com/tcarland/tca-kafka-go/config"
+
+    "github.com/confluentinc/confluent-kafka-go/v2/kafka"
+)
+
+
+// ActionType is the kind of change of a plan Action
+type ActionType int
+
+const (
+    ActionCreate ActionType = iota
+    ActionAddPartitions
+    ActionAlterConfigs
+    ActionWarn
+)
+
+
+/** Action is a change to a topic of the cluster given by Brokers.
+  * Partitions and Replicas are the desired counts, Configs the topic
+  * configs to set, and Detail describes the change. An ActionWarn is
+  * drift that cannot be fixed, such as a different replication factor
+  * or more partitions than desired, and is not applied.
+ **/
+type Action struct {
+    Type        ActionType
+    Brokers     string
+    Topic       string
+    Partitions  int
+    Replicas    int
+    Configs     map[string]string
+    Detail      string
+}
+
+
+// Plan is the list of actions reconciling the site topics, in order
+type Plan struct {
+    Actions  []Action
+}
+
+
+/** Reconciler plans and applies the topics of a set of sites. Sites
+  * may be of different clusters, and a topic defined by more than one
+  * site of a cluster uses the first definition, with a warning if the
+  * others differ. Topic patterns are skipped.
+ **/
+type Reconciler struct {
+    sites  []*config.KafkaSite
+}
+
+// -----------------------------------
+
+func NewReconciler(sites ...*config.KafkaSite) *Reconciler {
+    return new(Reconciler).InitReconciler(sites...)
+}
+
+
+func (r *Reconciler) InitReconciler(sites ...*config.KafkaSite) *Reconciler {
+    r.sites = sites
+    return r
+}
+
+
+/** Plan compares the site topics against their clusters, returning
+  * the actions needed without changing anything. Returns an ErrConfig
+  * error if a site is invalid, or the error describing a cluster.
+ **/
+func (r *Reconciler) Plan(ctx context.Context) (*Plan, error) {
+    for _, site := range r.sites {
+        site.SetDefaults()
+        if err := site.Validate(); err != nil {
+            return nil, newError("Reconciler.Plan", site.Brokers, ErrConfig, err)
+        }
+    }
+
+    plan := &Plan{ Actions: make([]Action, 0) }
+
+    for _, brokers := range r.clusters() {
+        specs, warnings := r.desired(brokers)
+        plan.Actions = append(plan.Actions, warnings...)
+
+        actions, err := r.planCluster(ctx, brokers, specs)
+        if err != nil {
+            return nil, err
+        }
+        plan.Actions = append(plan.Actions, actions...)
+    }
+    return plan, nil
+}
+
+
+/** Apply makes the changes of the plan, in order, skipping warnings.
+  * An action that fails does not stop the others, and the errors of
+  * all failed actions are returned joined.
+ **/
+func (r *Reconciler) Apply(ctx context.Context, plan *Plan) error {
+    admins := make(map[string]*Admin)
+    errs   := make([]error, 0)
+
+    defer func() {
+        for _, admin := range admins {
+            admin.Close()
+        }
+    }()
+
+    for _, act := range plan.Actions {
+        if act.Type == ActionWarn {
+            continue
+        }
+        admin, ok := admins[act.Brokers]
+        if ! ok {
+            admin = NewAdmin(r.site(act.Brokers))
+            admins[act.Brokers] = admin
+        }
+
+        if err := applyAction(ctx, admin, act); err != nil {
+            errs = append(errs, err)
+            continue
+        }
+        log.Printf("Reconciler.Apply() %s", act)
+    }
+    return errors.Join(errs...)
+}
+
+
+// Reconcile plans the site topics and applies the plan, unless dryRun
+func (r *Reconciler) Reconcile(ctx context.Context, dryRun bool) (*Plan, error) {
+    plan, err := r.Plan(ctx)
+    if err != nil || dryRun {
+        return plan, err
+    }
+    return plan, r.Apply(ctx, plan)
+}
+
+// -----------------------------------
+
+// clusters returns the brokers of the sites in order of appearance
+func (r *Reconciler) clusters() []string {
+    seen     := make(map[string]bool)
+    clusters := make([]string, 0)
+
+    for _, site := range r.sites {
+        if ! seen[site.Brokers] {
+            seen[site.Brokers] = true
+            clusters = append(clusters, site.Brokers)
+        }
+    }
+    return clusters
+}
+
+
+// site returns the first site of the given brokers
+func (r *Reconciler) site(brokers string) *config.KafkaSite {
+    for _, site := range r.sites {
+        if site.Brokers == brokers {
+            return site
+        }
+    }
+    return config.NewKafkaSite(brokers, "", "")
+}
+
+
+/** desired returns the topic specs of the sites of a cluster, and a
+  * warning for each topic defined again with different settings.
+ **/
+func (r *Reconciler) desired(brokers string) ([]TopicSpec, []Action) {
+    specs    := make([]TopicSpec, 0)
+    index    := make(map[string]int)
+    warnings := make([]Action, 0)
+
+    for _, site := range r.sites {
+        if site.Brokers != brokers {
+            continue
+        }
+        for _, topic := range site.TopicList() {
+            if config.IsTopicPattern(topic) {
+                continue
+            }
+            spec := TopicSpec{
+                Topic:      topic,
+                Partitions: site.Partitions,
+                Replicas:   site.Replicas,
+                Configs:    site.TopicConfigs,
+            }
+
+            i, ok := index[topic]
+            if ! ok {
+                index[topic] = len(specs)
+                specs = append(specs, spec)
+                continue
+            }
+            if ! sameSpec(specs[i], spec) {
+                warnings = append(warnings, Action{
+                    Type:    ActionWarn,
+                    Brokers: brokers,
+                    Topic:   topic,
+                    Detail:  "conflicting site definitions, using the first",
+                })
+            }
+        }
+    }
+    return specs, warnings
+}
+
+
+// planCluster describes the topics of a cluster and plans each of them
+func (r *Reconciler) planCluster(ctx context.Context, brokers string, specs []TopicSpec) ([]Action, error) {
+    if len(specs) == 0 {
+        return nil, nil
+    }
+
+    admin := NewAdmin(r.site(brokers))
+    defer admin.Close()
+
+    topics := make([]string, 0, len(specs))
+    for _, spec := range specs {
+        topics = append(topics, spec.Topic)
+    }
+
+    infos, err := admin.DescribeTopics(ctx, topics...)
+    if infos == nil {
+        return nil, err
+    }
+
+    existing := make(map[string]*TopicInfo)
+    for i := range infos {
+        info := &infos[i]
+        if info.Err != nil {
+            if ! isUnknownTopic(info.Err) {
+                return nil, info.Err
+            }
+            continue
+        }
+        existing[info.Topic] = info
+    }
+
+    actions := make([]Action, 0)
+    for _, spec := range specs {
+        info, ok := existing[spec.Topic]
+        if ! ok {
+            actions = append(actions, planTopic(brokers, spec, nil, nil)...)
+            continue
+        }
+
+        entries, err := admin.DescribeConfigs(ctx, spec.Topic)
+        if err != nil {
+            return nil, err
+        }
+        current := make(map[string]string, len(entries))
+        for _, ce := range entries {
+            if ! ce.Sensitive {
+                current[ce.Name] = ce.Value
+            }
+        }
+        actions = append(actions, planTopic(brokers, spec, info, current)...)
+    }
+    return actions, nil
+}
+
+
+/** planTopic returns the actions reconciling a topic with its spec,
+  * given its current description and configs, or a nil info if the
+  * topic does not exist. Only the configs that differ are altered.
+ **/
+func planTopic(brokers string, spec TopicSpec, info *TopicInfo, current map[string]string) []Action {
+    actions := make([]Action, 0)
+
+    if info == nil {
+        return append(actions, Action{
+            Type:       ActionCreate,
+            Brokers:    brokers,
+            Topic:      spec.Topic,
+            Partitions: spec.Partitions,
+            Replicas:   spec.Replicas,
+            Configs:    spec.Configs,
+            Detail:     fmt.Sprintf("create with %d partitions and %d replicas", spec.Partitions, spec.Replicas),
+        })
+    }
+
+    if n := len(info.Partitions); n < spec.Partitions {
+        actions = append(actions, Action{
+            Type:       ActionAddPartitions,
+            Brokers:    brokers,
+            Topic:      spec.Topic,
+            Partitions: spec.Partitions,
+            Detail:     fmt.Sprintf("increase partitions from %d to %d", n, spec.Partitions),
+        })
+    } else if n > spec.Partitions {
+        actions = append(actions, Action{
+            Type:       ActionWarn,
+            Brokers:    brokers,
+            Topic:      spec.Topic,
+            Partitions: spec.Partitions,
+            Detail:     fmt.Sprintf("has %d partitions, more than %d, which cannot be reduced", n, spec.Partitions),
+        })
+    }
+
+    if n := info.ReplicationFactor(); n != spec.Replicas {
+        actions = append(actions, Action{
+            Type:     ActionWarn,
+            Brokers:  brokers,
+            Topic:    spec.Topic,
+            Replicas: spec.Replicas,
+            Detail:   fmt.Sprintf("has %d replicas rather than %d, which requires a partition reassignment", n, spec.Replicas),
+        })
+    }
+
+    changed := make(map[string]string)
+    for name, value := range spec.Configs {
+        if cur, ok := current[name]; ! ok || cur != value {
+            changed[name] = value
+        }
+    }
+    if len(changed) > 0 {
+        actions = append(actions, Action{
+            Type:    ActionAlterConfigs,
+            Brokers: brokers,
+            Topic:   spec.Topic,
+            Configs: changed,
+            Detail:  "set " + formatConfigs(changed),
+        })
+    }
+    return actions
+}
+
+
+func applyAction(ctx context.Context, admin *Admin, act Action) error {
+    switch act.Type {
+    case ActionCreate:
+        _, err := admin.CreateTopics(ctx, TopicSpec{
+            Topic:      act.Topic,
+            Partitions: act.Partitions,
+            Replicas:   act.Replicas,
+            Configs:    act.Configs,
+        })
+        return err
+    case ActionAddPartitions:
+        return admin.CreatePartitions(ctx, act.Topic, act.Partitions)
+    case ActionAlterConfigs:
+        return admin.AlterConfigs(ctx, act.Topic, act.Configs)
+    }
+    return nil
+}
+
+// -----------------------------------
+
+func sameSpec(a TopicSpec, b TopicSpec) bool {
+    if a.Partitions != b.Partitions || a.Replicas != b.Replicas || len(a.Configs) != len(b.Configs) {
+        return false
+    }
+    for name, value := range a.Configs {
+        if v, ok := b.Configs[name]; ! ok || v != value {
+            return false
+        }
+    }
+    return true
+}
+
+
+// formatConfigs returns the configs as sorted 'name=value' pairs
+func formatConfigs(configs map[string]string) string {
+    pairs := make([]string, 0, len(configs))
+    for name, value := range configs {
+        pairs = append(pairs, name + "=" + value)
+    }
+    sort.Strings(pairs)
+    return strings.Join(pairs, ", ")
+}
+
+
+func isUnknownTopic(err error) bool {
+    var kerr kafka.Error
+    return errors.As(err, &kerr) && kerr.Code() == kafka.ErrUnknownTopicOrPart
+}
+
+
+func (t ActionType) String() string {
+    switch t {
+    case ActionCreate:
+        return "create"
+    case ActionAddPartitions:
+        return "add-partitions"
+    case ActionAlterConfigs:
+        return "alter-configs"
+    case ActionWarn:
+        return "warn"
+    }
+    return "unknown"
+}
+
+
+func (a Action) String() string {
+    return fmt.Sprintf("%s '%s' [%s]: %s", a.Type, a.Topic, a.Brokers, a.Detail)
+}
+
+
+// Changes returns the number of actions that Apply() would make
+func (p *Plan) Changes() int {
+    n := 0
+    for _, act := range p.Actions {
+        if act.Type != ActionWarn {
+            n++
+        }
+    }
+    return n
+}
+
+
+// String returns the plan actions, one per line
+func (p *Plan) String() string {
+    lines := make([]string, 0, len(p.Actions))
+    for _, act := range p.Actions {
+        lines = append(lines, act.String())
+    }
+    return strings.Join(lines, "\n")
+}
@@ -0,0 +1,112 @@
+package kafka
+
+import (
+    "context"
+    "errors"
+    "testing"
+
+    "github.com/tcarland/tca-kafka-go/config"
+)
+
+
+func topicInfo(partitions int, replicas int) *TopicInfo {
+    info := &TopicInfo{ Topic: "test" }
+    for i := 0; i < partitions; i++ {
+        info.Partitions = append(info.Partitions, PartitionInfo{
+            Partition: int32(i),
+            Replicas:  make([]int32, replicas),
+        })
+    }
+    return info
+}
+
+
+func TestPlanTopic(t *testing.T) {
+    t.Parallel()
+
+    spec := TopicSpec{
+        Topic:      "test",
+        Partitions: 6,
+        Replicas:   3,
+        Configs:    map[string]string{ "retention.ms": "86400000", "cleanup.policy": "delete" },
+    }
+    current := map[string]string{ "retention.ms": "604800000", "cleanup.policy": "delete" }
+
+    testCases := []struct {
+        name     string
+        info     *TopicInfo
+        current  map[string]string
+        extypes  []ActionType
+    }{
+        {"Missing topic", nil, nil, []ActionType{ ActionCreate }},
+        {"In sync", topicInfo(6, 3), spec.Configs, []ActionType{}},
+        {"Fewer partitions", topicInfo(3, 3), spec.Configs, []ActionType{ ActionAddPartitions }},
+        {"More partitions", topicInfo(12, 3), spec.Configs, []ActionType{ ActionWarn }},
+        {"Replica drift", topicInfo(6, 1), spec.Configs, []ActionType{ ActionWarn }},
+        {"Config drift", topicInfo(6, 3), current, []ActionType{ ActionAlterConfigs }},
+        {"All drift", topicInfo(1, 2), current, []ActionType{ ActionAddPartitions, ActionWarn, ActionAlterConfigs }},
+    }
+
+    for _, tc := range testCases {
+        t.Run(tc.name, func(t *testing.T) {
+            actions := planTopic("localhost:9092", spec, tc.info, tc.current)
+
+            if len(actions) != len(tc.extypes) {
+                t.Fatalf("Expecting actions %v but got: %v", tc.extypes, actions)
+            }
+            for i, act := range actions {
+                if act.Type != tc.extypes[i] || act.Topic != "test" {
+                    t.Errorf("Expecting a %v action but got: %v", tc.extypes[i], act)
+                }
+                if act.Type == ActionAlterConfigs && (len(act.Configs) != 1 || act.Configs["retention.ms"] != "86400000") {
+                    t.Errorf("Expecting only the changed config but got: %v", act.Configs)
+                }
+            }
+        })
+    }
+}
+
+
+func TestReconciler_Desired(t *testing.T) {
+    t.Parallel()
+
+    a := config.NewKafkaSite("localhost:9092", "orders", "")
+    b := config.NewKafkaSite("localhost:9092", "", "")
+    b.Topics     = []string{ "orders", "payments", "^logs.*" }
+    b.Partitions = 3
+    c := config.NewKafkaSite("otherhost:9092", "orders", "")
+
+    r := NewReconciler(a, b, c)
+
+    if clusters := r.clusters(); len(clusters) != 2 || clusters[0] != a.Brokers {
+        t.Fatalf("Expecting 2 clusters but got: %v", clusters)
+    }
+
+    specs, warnings := r.desired(a.Brokers)
+
+    if len(specs) != 2 || specs[0].Topic != "orders" || specs[0].Partitions != 1 || specs[1].Topic != "payments" {
+        t.Errorf("Expecting the first definition of 'orders' and 'payments' but got: %v", specs)
+    }
+    if len(warnings) != 1 || warnings[0].Type != ActionWarn || warnings[0].Topic != "orders" {
+        t.Errorf("Expecting a conflict warning for 'orders' but got: %v", warnings)
+    }
+}
+
+
+func TestReconciler_Config(t *testing.T) {
+    t.Parallel()
+
+    site := config.NewKafkaSite("", "test", "")
+
+    if _, err := NewReconciler(site).Reconcile(context.Background(), true); ! errors.Is(err, ErrConfig) {
+        t.Errorf("Expecting an ErrConfig error without brokers but got: %v", err)
+    }
+
+    plan := &Plan{ Actions: []Action{
+        { Type: ActionCreate, Topic: "a" },
+        { Type: ActionWarn,   Topic: "b" },
+    } }
+    if n := plan.Changes(); n != 1 {
+        t.Errorf("Expecting 1 change but got: %v", n)
+    }
+}
@@ -0,0 +1,276 @@
+/** kafka.Consumer retry tiers
+  *
+  *  Non-blocking retries through delayed retry topics. A message that
+  *  fails is sent to the retry topic of the next site RetryTiers, eg.
+  *  'topic.retry.1m', and redelivered to the handler by a retry consumer
+  *  of that tier once the delay given by its headers has elapsed. After
+  *  the last tier the message goes to the DLQTopic, if any.
+  *
+  *  Copyright (c) 2023-2026 Timothy C. Arland <tcarland at gmail dot com>
+ **/
+package kafka
+
+import (
+    "context"
+    "errors"
+    "log"
+    "strconv"
+    "strings"
+    "sync"
+    "time"
+
+    "github.com/tcarland/tca-kafka-go/config"
+)
+
+
+// Headers added to messages sent to a retry topic
+const (
+    HeaderRetryAttempt   = "x-retry-attempt"
+    HeaderRetryNotBefore = "x-retry-not-before"
+    HeaderRetryTopic     = "x-retry-origin-topic"
+    HeaderRetryPartition = "x-retry-origin-partition"
+    HeaderRetryOffset    = "x-retry-origin-offset"
+    HeaderRetryError     = "x-retry-error"
+)
+
+const retryHeaderPrefix = "x-retry-"
+
+// -----------------------------------
+
+/** origin returns the original position of a message and the number
+  * of handler attempts already made, as given by the retry headers of
+  * a redelivered message, or the message itself otherwise.
+ **/
+func origin(m *Message) (string, int32, int64, int) {
+    topic, partition, offset, attempts := m.Topic, m.Partition, m.Offset, 0
+
+    if v, ok := m.GetHeader(HeaderRetryTopic); ok {
+        topic = string(v)
+    }
+    if v, ok := m.GetHeader(HeaderRetryPartition); ok {
+        if n, err := strconv.ParseInt(string(v), 10, 32); err == nil {
+            partition = int32(n)
+        }
+    }
+    if v, ok := m.GetHeader(HeaderRetryOffset); ok {
+        if n, err := strconv.ParseInt(string(v), 10, 64); err == nil {
+            offset = n
+        }
+    }
+    if v, ok := m.GetHeader(HeaderRetryAttempt); ok {
+        if n, err := strconv.Atoi(string(v)); err == nil {
+            attempts = n
+        }
+    }
+    return topic, partition, offset, attempts
+}
+
+
+// failedRecord returns the message as a Record without its retry headers
+func failedRecord(m *Message) *Record {
+    rec := m.Record()
+    headers := rec.Headers[:0]
+
+    for _, h := range rec.Headers {
+        if ! strings.HasPrefix(h.Key, retryHeaderPrefix) {
+            headers = append(headers, h)
+        }
+    }
+    rec.Headers = headers
+    return rec
+}
+
+// -----------------------------------
+
+/** failed sends on a message whose handler failed, to the next retry
+  * tier or to the dead-letter topic after the last tier. Returns nil
+  * once delivered, or the handler error if there is nowhere to send
+  * the message or the context is done.
+ **/
+func (c *Consumer) failed(ctx context.Context, m *Message, herr error, attempts int) error {
+    switch {
+    case ctx.Err() != nil:
+        return herr
+    case c.tier < len(c.site.RetryTiers):
+        return c.sendRetry(ctx, m, herr, attempts)
+    case c.site.DLQTopic != "":
+        return c.deadLetter(ctx, m, herr, attempts)
+    }
+    return herr
+}
+
+
+/** sendRetry sends the message to the retry topic of the next tier
+  * with a not-before time of now plus the tier delay, and waits for
+  * its delivery. The retry attempt counts all handler attempts made.
+ **/
+func (c *Consumer) sendRetry(ctx context.Context, m *Message, herr error, attempts int) error {
+    delays, err := c.site.RetryDelays()
+    if err != nil {
+        return errors.Join(herr, err)
+    }
+
+    topic, partition, offset, prior := origin(m)
+    tier      := c.site.RetryTiers[c.tier]
+    notBefore := time.Now().Add(delays[c.tier]).UnixMilli()
+
+    rec := failedRecord(m)
+    rec.Topic   = config.RetryTopic(topic, tier)
+    rec.Headers = append(rec.Headers,
+        Header{ Key: HeaderRetryAttempt,   Value: []byte(strconv.Itoa(prior + attempts)) },
+        Header{ Key: HeaderRetryNotBefore, Value: []byte(strconv.FormatInt(notBefore, 10)) },
+        Header{ Key: HeaderRetryTopic,     Value: []byte(topic) },
+        Header{ Key: HeaderRetryPartition, Value: []byte(strconv.Itoa(int(partition))) },
+        Header{ Key: HeaderRetryOffset,    Value: []byte(strconv.FormatInt(offset, 10)) },
+        Header{ Key: HeaderRetryError,     Value: []byte(herr.Error()) },
+    )
+
+    if _, err := c.out.SendSync(ctx, rec); err != nil {
+        return errors.Join(herr, err)
+    }
+
+    log.Printf("Consumer.sendRetry() '%s' sent %s [%d] offset %d to '%s'", c.name, topic, partition, offset, rec.Topic)
+    return nil
+}
+
+// -----------------------------------
+
+/** runRetries creates the retry topics, if needed, and starts a retry
+  * consumer of each tier, added to wg until its Consume() returns.
+  * Retry consumers share the Producer and the Errors() channel of this
+  * Consumer and stop with the context.
+ **/
+func (c *Consumer) runRetries(ctx context.Context, wg *sync.WaitGroup) {
+    if len(c.site.RetryTiers) == 0 {
+        return
+    }
+
+    if err := c.createRetryTopics(ctx); err != nil {
+        log.Printf("Consumer.runRetries() '%s' error creating retry topics: %v", c.name, err)
+        c.sendError(err)
+    }
+
+    for i, tier := range c.site.RetryTiers {
+        rc := NewConsumer(c.name + "-retry-" + tier, c.retrySite(tier))
+        rc.tier    = i + 1
+        rc.out     = c.out
+        rc.errc    = c.errc
+        rc.metrics = c.metrics
+        rc.handler = HandlerFunc(c.redeliver)
+
+        go rc.Process(ctx)
+        wg.Add(1)
+        go func() {
+            defer wg.Done()
+            if err := rc.Consume(ctx); err != nil {
+                log.Printf("Consumer.runRetries() '%s' error: %v", rc.name, err)
+                rc.sendError(err)
+            }
+        }()
+    }
+}
+
+
+// createRetryTopics creates any missing retry topics of the site topics
+func (c *Consumer) createRetryTopics(ctx context.Context) error {
+    specs := make([]TopicSpec, 0)
+
+    for _, topic := range c.site.TopicList() {
+        for _, tier := range c.site.RetryTiers {
+            specs = append(specs, TopicSpec{
+                Topic:      config.RetryTopic(topic, tier),
+                Partitions: c.site.Partitions,
+                Replicas:   c.site.Replicas,
+            })
+        }
+    }
+
+    admin := NewAdmin(c.site)
+    defer admin.Close()
+
+    results, err := admin.CreateTopics(ctx, specs...)
+    if results == nil {
+        return err
+    }
+
+    errs := make([]error, 0)
+    for _, res := range results {
+        if res.Err != nil && ! IsTopicExists(res.Err) {
+            errs = append(errs, res.Err)
+        }
+    }
+    return errors.Join(errs...)
+}
+
+
+/** retrySite returns the site of a retry tier consumer, subscribed to
+  * the tier topics with its own group id. Retry consumers commit their
+  * offsets manually, start from the earliest offset of a new group and
+  * always use flow control, so waiting in the handler pauses the
+  * partitions rather than blocking the client. Their lag is expected
+  * to grow with the tier delay and is not monitored.
+ **/
+func (c *Consumer) retrySite(tier string) *config.KafkaSite {
+    site := *c.site
+    site.Topic        = ""
+    site.Topics       = make([]string, 0)
+    site.GroupId      = c.site.GroupId + "-retry-" + tier
+    site.ManualCommit = true
+    site.DoReset      = false
+    site.StartFrom    = config.StartCommitted
+    site.StartOffsets = nil
+    site.LagMs        = 0
+    site.Properties   = make(map[string]string)
+
+    for _, topic := range c.site.TopicList() {
+        site.Topics = append(site.Topics, config.RetryTopic(topic, tier))
+    }
+    for k, v := range c.site.Properties {
+        site.Properties[k] = v
+    }
+    site.Properties["auto.offset.reset"] = "earliest"
+
+    if site.HighWater == 0 {
+        site.HighWater = 1
+        site.LowWater  = 0
+    }
+    return &site
+}
+
+
+// redeliver is the Handler of the retry consumers, calling the Handler,
+// or the BatchHandler with a batch of one.
+func (c *Consumer) redeliver(ctx context.Context, m *Message) error {
+    if c.batch != nil {
+        return c.batch.HandleBatch(ctx, []*Message{ m })
+    }
+    return c.handler.Handle(ctx, m)
+}
+
+
+// waitRetry waits until the not-before time of a message, if any
+func waitRetry(ctx context.Context, m *Message) error {
+    v, ok := m.GetHeader(HeaderRetryNotBefore)
+    if ! ok {
+        return nil
+    }
+    ms, err := strconv.ParseInt(string(v), 10, 64)
+    if err != nil {
+        return nil
+    }
+
+    wait := time.Until(time.UnixMilli(ms))
+    if wait <= 0 {
+        return nil
+    }
+
+    timer := time.NewTimer(wait)
+    defer timer.Stop()
+
+    select {
+    case <- ctx.Done():
+        return ctx.Err()
+    case <- timer.C:
+    }
+    return nil
+}
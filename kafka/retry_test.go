@@ -0,0 +1,136 @@
+package kafka
+
+import (
+    "context"
+    "strconv"
+    "testing"
+    "time"
+
+    "github.com/tcarland/tca-kafka-go/config"
+)
+
+
+func TestOrigin(t *testing.T) {
+    t.Parallel()
+
+    testCases := []struct {
+        name       string
+        headers    []Header
+        extopic    string
+        expart     int32
+        exoffset   int64
+        exattempts int
+    }{
+        {"First delivery", nil, "test.retry.1m", 1, 10, 0},
+        {"Redelivery", []Header{
+            { Key: HeaderRetryTopic,     Value: []byte("test") },
+            { Key: HeaderRetryPartition, Value: []byte("3") },
+            { Key: HeaderRetryOffset,    Value: []byte("42") },
+            { Key: HeaderRetryAttempt,   Value: []byte("4") },
+        }, "test", 3, 42, 4},
+        {"Invalid headers", []Header{
+            { Key: HeaderRetryPartition, Value: []byte("x") },
+            { Key: HeaderRetryAttempt,   Value: []byte("") },
+        }, "test.retry.1m", 1, 10, 0},
+    }
+
+    for _, tc := range testCases {
+        t.Run(tc.name, func(t *testing.T) {
+            m := &Message{ Topic: "test.retry.1m", Partition: 1, Offset: 10, Headers: tc.headers }
+
+            topic, part, offset, attempts := origin(m)
+            if topic != tc.extopic || part != tc.expart || offset != tc.exoffset || attempts != tc.exattempts {
+                t.Errorf("Expecting %v [%v] %v after %v attempts but got: %v [%v] %v after %v attempts",
+                    tc.extopic, tc.expart, tc.exoffset, tc.exattempts, topic, part, offset, attempts)
+            }
+        })
+    }
+}
+
+
+func TestFailedRecord(t *testing.T) {
+    t.Parallel()
+
+    m := &Message{ Topic: "test", Headers: []Header{
+        { Key: "trace",              Value: []byte("abc") },
+        { Key: HeaderRetryAttempt,   Value: []byte("2") },
+        { Key: HeaderRetryNotBefore, Value: []byte("0") },
+    } }
+
+    rec := failedRecord(m)
+    if len(rec.Headers) != 1 || rec.Headers[0].Key != "trace" {
+        t.Errorf("Expecting only the trace header but got: %v", rec.Headers)
+    }
+    if len(m.Headers) != 3 {
+        t.Errorf("Expecting the message headers to be unchanged but got: %v", m.Headers)
+    }
+}
+
+
+func TestWaitRetry(t *testing.T) {
+    t.Parallel()
+
+    notBefore := func(d time.Duration) []Header {
+        ms := time.Now().Add(d).UnixMilli()
+        return []Header{ { Key: HeaderRetryNotBefore, Value: []byte(strconv.FormatInt(ms, 10)) } }
+    }
+
+    testCases := []struct {
+        name     string
+        headers  []Header
+        minwait  time.Duration
+        exerr    bool
+    }{
+        {"No header", nil, 0, false},
+        {"Elapsed", notBefore(-time.Minute), 0, false},
+        {"Delayed", notBefore(50 * time.Millisecond), 40 * time.Millisecond, false},
+        {"Context done", notBefore(time.Hour), 0, true},
+    }
+
+    for _, tc := range testCases {
+        t.Run(tc.name, func(t *testing.T) {
+            ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+            defer cancel()
+
+            start := time.Now()
+            err   := waitRetry(ctx, &Message{ Headers: tc.headers })
+
+            if (err != nil) != tc.exerr {
+                t.Errorf("Expecting error %v but got: %v", tc.exerr, err)
+            }
+            if waited := time.Since(start); waited < tc.minwait {
+                t.Errorf("Expecting a wait of at least %v but got: %v", tc.minwait, waited)
+            }
+        })
+    }
+}
+
+
+func TestConsumer_RetrySite(t *testing.T) {
+    t.Parallel()
+
+    site := config.NewKafkaSite("localhost:9092", "test", "grp1")
+    site.Topics     = []string{"other"}
+    site.RetryTiers = []string{"1m", "10m"}
+    site.DLQTopic   = "test.dlq"
+    site.HighWater  = 0
+
+    c  := NewConsumer("test", site)
+    rs := c.retrySite("10m")
+
+    if rs.GroupId != "grp1-retry-10m" {
+        t.Errorf("Expecting group 'grp1-retry-10m' but got: %v", rs.GroupId)
+    }
+    if topics := rs.TopicList(); len(topics) != 2 || topics[0] != "test.retry.10m" || topics[1] != "other.retry.10m" {
+        t.Errorf("Expecting the 10m retry topics but got: %v", topics)
+    }
+    if ! rs.ManualCommit || rs.HighWater < 1 {
+        t.Errorf("Expecting manual commits and flow control but got: %v %v", rs.ManualCommit, rs.HighWater)
+    }
+    if _, ok := site.Properties["auto.offset.reset"]; ok {
+        t.Errorf("Expecting the site properties to be unchanged but got: %v", site.Properties)
+    }
+    if err := rs.ValidateConsumer(); err != nil {
+        t.Errorf("Unexpected error validating the retry site: %v", err)
+    }
+}
@@ -0,0 +1,146 @@
+/** kafka.Consumer partition rewinds
+  *
+  *  At-least-once handling of messages that still fail with no retry
+  *  tier or dead-letter topic to send them on. The partition of the
+  *  failed message is sought back to it so that it is read again, and
+  *  the messages of the partition read before the seek are passed over,
+  *  so no later acknowledgment commits past the failed offset.
+  *
+  *  Copyright (c) 2023-2026 Timothy C. Arland <tcarland at gmail dot com>
+ **/
+package kafka
+
+import (
+    "context"
+    "log"
+    "sync"
+
+    "github.com/confluentinc/confluent-kafka-go/v2/kafka"
+)
+
+
+// rewinder holds the partition seeks yet to be made by Consume()
+type rewinder struct {
+    lock   sync.Mutex
+    seeks  map[string]kafka.TopicPartition
+}
+
+// -----------------------------------
+
+func newRewinder() *rewinder {
+    return &rewinder{ seeks: make(map[string]kafka.TopicPartition) }
+}
+
+
+// add requests a seek of a partition, keeping the lowest offset of
+// any seek already pending.
+func (r *rewinder) add(tp kafka.TopicPartition) {
+    r.lock.Lock()
+    defer r.lock.Unlock()
+
+    key := partitionKey(*tp.Topic, tp.Partition)
+    if prev, ok := r.seeks[key]; ok && prev.Offset <= tp.Offset {
+        return
+    }
+    r.seeks[key] = tp
+}
+
+
+// pending reports whether a seek of the partition is yet to be made
+func (r *rewinder) pending(topic string, partition int32) bool {
+    r.lock.Lock()
+    defer r.lock.Unlock()
+
+    _, ok := r.seeks[partitionKey(topic, partition)]
+    return ok
+}
+
+
+/** take returns and clears the pending seeks, first calling advance
+  * with them while holding the lock, so that a partition is no longer
+  * pending only once advance has returned.
+ **/
+func (r *rewinder) take(advance func([]kafka.TopicPartition)) []kafka.TopicPartition {
+    r.lock.Lock()
+    defer r.lock.Unlock()
+
+    parts := make([]kafka.TopicPartition, 0, len(r.seeks))
+    for _, tp := range r.seeks {
+        parts = append(parts, tp)
+    }
+    if len(parts) == 0 {
+        return parts
+    }
+
+    advance(parts)
+    for _, tp := range parts {
+        delete(r.seeks, partitionKey(*tp.Topic, tp.Partition))
+    }
+    return parts
+}
+
+
+// drop clears the pending seeks of the given partitions, such as
+// when they are revoked.
+func (r *rewinder) drop(parts []kafka.TopicPartition) {
+    r.lock.Lock()
+    defer r.lock.Unlock()
+
+    for _, tp := range parts {
+        delete(r.seeks, partitionKey(*tp.Topic, tp.Partition))
+    }
+}
+
+// -----------------------------------
+
+/** passedOver reports whether a message is to be passed over without
+  * being handled or acknowledged, being read before the partition was
+  * reassigned or rewound, or while a rewind of it is pending. Messages
+  * are never passed over when offsets are stored by the client. The
+  * pending rewind is checked first, as the generation advances before
+  * the rewind is cleared.
+ **/
+func (c *Consumer) passedOver(m *Message) bool {
+    if ! c.manualCommit() {
+        return false
+    }
+    return c.rewinds.pending(m.Topic, m.Partition) ||
+        m.gen != c.tracker.generation(m.Topic, m.Partition)
+}
+
+
+/** rewind requests the partition of a failed message be sought back to
+  * it, or to the lowest offset of the partition still being handled by
+  * the worker pool, advancing the partition generation so the messages
+  * read since are passed over. Nothing is rewound when the context is
+  * done or the message was itself passed over.
+ **/
+func (c *Consumer) rewind(ctx context.Context, m *Message) {
+    if ctx.Err() != nil || ! c.manualCommit() || c.passedOver(m) {
+        return
+    }
+
+    tp := m.topicPartition()
+    if low, ok := c.tracker.lowest(m); ok && low < m.Offset {
+        tp.Offset = kafka.Offset(low)
+    }
+
+    c.tracker.remove([]kafka.TopicPartition{ tp })
+    c.rewinds.add(tp)
+    log.Printf("Consumer.rewind() '%s' rewinding %s [%d] to offset %d", c.name, m.Topic, m.Partition, tp.Offset)
+}
+
+
+// seekRewinds makes the pending rewinds from the Consume() loop, so that
+// messages read after the seek are of the new partition generation.
+func (c *Consumer) seekRewinds() {
+    parts := c.rewinds.take(c.tracker.remove)
+    if len(parts) == 0 {
+        return
+    }
+
+    if err := c.SeekPartitions(parts); err != nil {
+        log.Printf("Consumer.Consume() '%s' rewind error: %v", c.name, err)
+        c.sendError(err)
+    }
+}
@@ -0,0 +1,83 @@
+package kafka
+
+import (
+    "context"
+    "testing"
+
+    "github.com/tcarland/tca-kafka-go/config"
+)
+
+
+func TestConsumer_Rewind(t *testing.T) {
+    t.Parallel()
+
+    testCases := []struct {
+        name      string
+        manual    bool
+        inflight  []int64
+        failed    int64
+        exoffset  int64
+        exrewind  bool
+    }{
+        {"Failed offset", true, []int64{}, 7, 7, true},
+        {"Lowest in-flight offset", true, []int64{5, 6, 7}, 7, 5, true},
+        {"Client stored offsets", false, []int64{}, 7, 0, false},
+    }
+
+    for _, tc := range testCases {
+        t.Run(tc.name, func(t *testing.T) {
+            site := config.NewKafkaSite("localhost:9092", "test", "grp1")
+            site.ManualCommit = tc.manual
+
+            c := NewConsumer("test", site)
+            for _, off := range tc.inflight {
+                c.tracker.start(&Message{ Topic: "test", Partition: 0, Offset: off })
+            }
+
+            failed := &Message{ Topic: "test", Partition: 0, Offset: tc.failed }
+            next   := &Message{ Topic: "test", Partition: 0, Offset: tc.failed + 1 }
+            c.rewind(context.Background(), failed)
+
+            if c.passedOver(next) != tc.exrewind {
+                t.Errorf("Expecting the next message passed over to be %v", tc.exrewind)
+            }
+
+            parts := c.rewinds.take(c.tracker.remove)
+            if ! tc.exrewind {
+                if len(parts) != 0 {
+                    t.Errorf("Expecting no rewind but got: %v", parts)
+                }
+                return
+            }
+            if len(parts) != 1 || int64(parts[0].Offset) != tc.exoffset {
+                t.Fatalf("Expecting a rewind to offset %v but got: %v", tc.exoffset, parts)
+            }
+
+            // a failure of a message read before the rewind is not rewound again
+            c.rewind(context.Background(), next)
+            if parts = c.rewinds.take(c.tracker.remove); len(parts) != 0 {
+                t.Errorf("Expecting no rewind of a passed over message but got: %v", parts)
+            }
+            if _, ok := c.tracker.finish(&Message{ Topic: "test", Partition: 0, Offset: tc.exoffset }); ok {
+                t.Errorf("Expecting no offset to store for a message read before the rewind")
+            }
+        })
+    }
+}
+
+
+func TestConsumer_RewindDone(t *testing.T) {
+    t.Parallel()
+
+    site := config.NewKafkaSite("localhost:9092", "test", "grp1")
+    site.ManualCommit = true
+    c := NewConsumer("test", site)
+
+    ctx, cancel := context.WithCancel(context.Background())
+    cancel()
+    c.rewind(ctx, &Message{ Topic: "test", Partition: 0, Offset: 1 })
+
+    if parts := c.rewinds.take(c.tracker.remove); len(parts) != 0 {
+        t.Errorf("Expecting no rewind once the context is done but got: %v", parts)
+    }
+}
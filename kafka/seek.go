@@ -0,0 +1,199 @@
+/** kafka.Consumer start positions and seeking
+  *
+  *  Applies the KafkaSite StartFrom and StartOffsets positions on the
+  *  first assignment of each partition, and provides seeking of the
+  *  assigned partitions of a running consumer.
+  *
+  *  Copyright (c) 2023-2026 Timothy C. Arland <tcarland at gmail dot com>
+ **/
+package kafka
+
+import (
+    "errors"
+    "fmt"
+    "log"
+    "time"
+
+    "github.com/tcarland/tca-kafka-go/config"
+
+    "github.com/confluentinc/confluent-kafka-go/v2/kafka"
+)
+
+
+const offsetsTimeoutMs = 10000
+
+var errNotRunning = errors.New("consumer is not running")
+
+// -----------------------------------
+
+func partitionKey(topic string, partition int32) string {
+    return fmt.Sprintf("%s/%d", topic, partition)
+}
+
+
+// client returns the consumer handle while Consume() is running
+func (c *Consumer) client() (*kafka.Consumer, error) {
+    c.lock.Lock()
+    defer c.lock.Unlock()
+
+    if c.consumer == nil {
+        return nil, errNotRunning
+    }
+    return c.consumer, nil
+}
+
+
+/** startOffsets sets the site start position of newly assigned
+  * partitions that have not been assigned to this consumer before.
+  * Later assignments resume from the committed offsets.
+ **/
+func (c *Consumer) startOffsets(consumer *kafka.Consumer, parts []kafka.TopicPartition) error {
+    if c.site.StartFrom == config.StartCommitted && len(c.site.StartOffsets) == 0 {
+        return nil
+    }
+
+    ts, isTime, err := c.site.StartTime()
+    if err != nil {
+        return err
+    }
+
+    times := make([]kafka.TopicPartition, 0)
+
+    for i := range parts {
+        tp  := &parts[i]
+        key := partitionKey(*tp.Topic, tp.Partition)
+        if c.started[key] {
+            continue
+        }
+        c.started[key] = true
+
+        if off, ok := c.site.StartOffsets[tp.Partition]; ok && *tp.Topic == c.site.Topic {
+            tp.Offset = kafka.Offset(off)
+            continue
+        }
+
+        switch {
+        case c.site.StartFrom == config.StartEarliest:
+            tp.Offset = kafka.OffsetBeginning
+        case c.site.StartFrom == config.StartLatest:
+            tp.Offset = kafka.OffsetEnd
+        case isTime:
+            times = append(times, kafka.TopicPartition{
+                Topic:     tp.Topic,
+                Partition: tp.Partition,
+                Offset:    kafka.Offset(ts.UnixMilli()),
+            })
+        }
+    }
+
+    if len(times) == 0 {
+        return nil
+    }
+
+    offsets, err := consumer.OffsetsForTimes(times, offsetsTimeoutMs)
+    if err != nil {
+        return err
+    }
+    for _, off := range offsets {
+        for i := range parts {
+            if *parts[i].Topic == *off.Topic && parts[i].Partition == off.Partition {
+                parts[i].Offset = off.Offset
+            }
+        }
+    }
+
+    log.Printf("Consumer.startOffsets() '%s' starting %d partitions from %v", c.name, len(offsets), ts)
+    return nil
+}
+
+// -----------------------------------
+
+// Seek moves an assigned partition of the running consumer to offset
+func (c *Consumer) Seek(topic string, partition int32, offset int64) error {
+    return c.SeekPartitions([]TopicPartition{ {
+        Topic:     &topic,
+        Partition: partition,
+        Offset:    kafka.Offset(offset),
+    } })
+}
+
+
+// SeekPartitions moves each of the given assigned partitions to its offset
+func (c *Consumer) SeekPartitions(partitions []TopicPartition) error {
+    consumer, err := c.client()
+    if err != nil {
+        return newError("Consumer.Seek", c.name, ErrState, err)
+    }
+
+    results, err := consumer.SeekPartitions(partitions)
+    if err != nil {
+        return kafkaError("Consumer.Seek", c.name, err)
+    }
+
+    errs := make([]error, 0)
+    for _, tp := range results {
+        if tp.Error != nil {
+            errs = append(errs, kafkaError("Consumer.Seek", c.name, tp.Error))
+        }
+    }
+    return errors.Join(errs...)
+}
+
+
+// SeekToTime moves all assigned partitions to the first offset at
+// or after the given time, found via the broker offsets-for-times.
+func (c *Consumer) SeekToTime(t time.Time) error {
+    parts, err := c.seekAssignment(kafka.Offset(t.UnixMilli()))
+    if err != nil {
+        return err
+    }
+
+    consumer, err := c.client()
+    if err != nil {
+        return newError("Consumer.SeekToTime", c.name, ErrState, err)
+    }
+
+    offsets, err := consumer.OffsetsForTimes(parts, offsetsTimeoutMs)
+    if err != nil {
+        return kafkaError("Consumer.SeekToTime", c.name, err)
+    }
+    return c.SeekPartitions(offsets)
+}
+
+
+// SeekToBeginning moves all assigned partitions to the earliest offset
+func (c *Consumer) SeekToBeginning() error {
+    parts, err := c.seekAssignment(kafka.OffsetBeginning)
+    if err != nil {
+        return err
+    }
+    return c.SeekPartitions(parts)
+}
+
+
+// SeekToEnd moves all assigned partitions to the latest offset
+func (c *Consumer) SeekToEnd() error {
+    parts, err := c.seekAssignment(kafka.OffsetEnd)
+    if err != nil {
+        return err
+    }
+    return c.SeekPartitions(parts)
+}
+
+
+// seekAssignment returns the current assignment set to offset
+func (c *Consumer) seekAssignment(offset kafka.Offset) ([]kafka.TopicPartition, error) {
+    consumer, err := c.client()
+    if err != nil {
+        return nil, newError("Consumer.Seek", c.name, ErrState, err)
+    }
+
+    parts, err := consumer.Assignment()
+    if err != nil {
+        return nil, kafkaError("Consumer.Seek", c.name, err)
+    }
+    for i := range parts {
+        parts[i].Offset = offset
+    }
+    return parts, nil
+}
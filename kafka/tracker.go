@@ -0,0 +1,158 @@
+/** kafka.Consumer offset tracking
+  *
+  *  Tracks the in-flight offsets of each partition when messages are
+  *  handled out of order by the worker pool, so that only offsets below
+  *  the lowest unfinished offset of a partition are committed.
+  *
+  *  Copyright (c) 2023-2026 Timothy C. Arland <tcarland at gmail dot com>
+ **/
+package kafka
+
+import (
+    "sync"
+
+    "github.com/confluentinc/confluent-kafka-go/v2/kafka"
+)
+
+
+// partitionOffsets holds the in-flight offsets of a partition in the
+// order read, marking those of them that are finished, for the
+// generation of the partition they were read in.
+type partitionOffsets struct {
+    gen      uint64
+    offsets  []int64
+    done     map[int64]bool
+}
+
+
+/** offsetTracker holds the in-flight offsets and the generation of each
+  * partition. The generation advances each time a partition is removed,
+  * and messages are tagged with it when read, so messages read before
+  * a revoke or reassignment are no longer tracked.
+ **/
+type offsetTracker struct {
+    lock   sync.Mutex
+    parts  map[string]*partitionOffsets
+    gens   map[string]uint64
+}
+
+// -----------------------------------
+
+func newOffsetTracker() *offsetTracker {
+    return &offsetTracker{
+        parts: make(map[string]*partitionOffsets),
+        gens:  make(map[string]uint64),
+    }
+}
+
+
+// generation returns the current generation of a partition
+func (t *offsetTracker) generation(topic string, partition int32) uint64 {
+    t.lock.Lock()
+    defer t.lock.Unlock()
+
+    return t.gens[partitionKey(topic, partition)]
+}
+
+
+// start adds a message read from its partition as in-flight, unless
+// it was read in an earlier generation of the partition.
+func (t *offsetTracker) start(m *Message) {
+    t.lock.Lock()
+    defer t.lock.Unlock()
+
+    key := partitionKey(m.Topic, m.Partition)
+    if m.gen != t.gens[key] {
+        return
+    }
+
+    po := t.parts[key]
+    if po == nil || po.gen != m.gen {
+        po = &partitionOffsets{ gen: m.gen, done: make(map[int64]bool) }
+        t.parts[key] = po
+    }
+    po.offsets = append(po.offsets, m.Offset)
+    po.done[m.Offset] = false
+}
+
+
+/** finish marks a message as finished. When it was the lowest in-flight
+  * offset of its partition, the partition position advances past every
+  * consecutive finished message and the last of them is returned with
+  * true, otherwise the position is unchanged and finish returns false.
+  * Messages that are not in-flight in the current generation of their
+  * partition are ignored.
+ **/
+func (t *offsetTracker) finish(m *Message) (kafka.TopicPartition, bool) {
+    t.lock.Lock()
+    defer t.lock.Unlock()
+
+    po := t.parts[partitionKey(m.Topic, m.Partition)]
+    if po == nil || po.gen != m.gen {
+        return kafka.TopicPartition{}, false
+    }
+    if done, ok := po.done[m.Offset]; ! ok || done {
+        return kafka.TopicPartition{}, false
+    }
+    po.done[m.Offset] = true
+
+    last := int64(-1)
+    for len(po.offsets) > 0 && po.done[po.offsets[0]] {
+        last = po.offsets[0]
+        delete(po.done, last)
+        po.offsets = po.offsets[1:]
+    }
+
+    if last < 0 {
+        return kafka.TopicPartition{}, false
+    }
+    tp := m.topicPartition()
+    tp.Offset = kafka.Offset(last)
+    return tp, true
+}
+
+
+// lowest returns the lowest in-flight offset of the partition of a
+// message in its generation, if any.
+func (t *offsetTracker) lowest(m *Message) (int64, bool) {
+    t.lock.Lock()
+    defer t.lock.Unlock()
+
+    po := t.parts[partitionKey(m.Topic, m.Partition)]
+    if po == nil || po.gen != m.gen || len(po.offsets) == 0 {
+        return 0, false
+    }
+    return po.offsets[0], true
+}
+
+
+// remove drops the in-flight offsets of the given partitions, such as
+// when they are revoked, and advances their generation so late
+// finishing messages are not stored.
+func (t *offsetTracker) remove(parts []kafka.TopicPartition) {
+    t.lock.Lock()
+    defer t.lock.Unlock()
+
+    for _, tp := range parts {
+        key := partitionKey(*tp.Topic, tp.Partition)
+        delete(t.parts, key)
+        t.gens[key]++
+    }
+}
+
+
+// pending returns the number of unfinished offsets of all partitions
+func (t *offsetTracker) pending() int {
+    t.lock.Lock()
+    defer t.lock.Unlock()
+
+    n := 0
+    for _, po := range t.parts {
+        for _, done := range po.done {
+            if ! done {
+                n++
+            }
+        }
+    }
+    return n
+}
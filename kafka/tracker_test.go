@@ -0,0 +1,114 @@
+package kafka
+
+import (
+    "testing"
+)
+
+
+func TestOffsetTracker_Finish(t *testing.T) {
+    t.Parallel()
+
+    testCases := []struct {
+        name      string
+        started   []int64
+        finished  []int64
+        exstored  []int64
+        expending int
+    }{
+        {"In order", []int64{1, 2, 3}, []int64{1, 2, 3}, []int64{1, 2, 3}, 0},
+        {"Out of order", []int64{1, 2, 3}, []int64{3, 2, 1}, []int64{3}, 0},
+        {"Lowest unfinished", []int64{1, 2, 3, 4}, []int64{2, 1, 4}, []int64{2}, 1},
+        {"Offset gaps", []int64{10, 15, 20}, []int64{15, 10, 20}, []int64{15, 20}, 0},
+        {"Nothing finished", []int64{1, 2}, []int64{}, []int64{}, 2},
+    }
+
+    for _, tc := range testCases {
+        t.Run(tc.name, func(t *testing.T) {
+            tracker := newOffsetTracker()
+            for _, off := range tc.started {
+                tracker.start(&Message{ Topic: "test", Partition: 0, Offset: off })
+            }
+
+            stored := make([]int64, 0)
+            for _, off := range tc.finished {
+                if tp, ok := tracker.finish(&Message{ Topic: "test", Partition: 0, Offset: off }); ok {
+                    stored = append(stored, int64(tp.Offset))
+                }
+            }
+
+            if len(stored) != len(tc.exstored) {
+                t.Fatalf("Expecting stored offsets %v but got: %v", tc.exstored, stored)
+            }
+            for i := range stored {
+                if stored[i] != tc.exstored[i] {
+                    t.Errorf("Expecting stored offsets %v but got: %v", tc.exstored, stored)
+                }
+            }
+            if n := tracker.pending(); n != tc.expending {
+                t.Errorf("Expecting %v pending offsets but got: %v", tc.expending, n)
+            }
+        })
+    }
+}
+
+
+func TestOffsetTracker_Partitions(t *testing.T) {
+    t.Parallel()
+
+    tracker := newOffsetTracker()
+    tracker.start(&Message{ Topic: "test", Partition: 0, Offset: 1 })
+    tracker.start(&Message{ Topic: "test", Partition: 1, Offset: 1 })
+    tracker.start(&Message{ Topic: "test", Partition: 1, Offset: 2 })
+
+    if tp, ok := tracker.finish(&Message{ Topic: "test", Partition: 1, Offset: 1 }); ! ok || tp.Partition != 1 {
+        t.Errorf("Expecting partition 1 to advance independently but got: %v %v", tp, ok)
+    }
+
+    topic := "test"
+    tracker.remove([]TopicPartition{ { Topic: &topic, Partition: 0 } })
+
+    if _, ok := tracker.finish(&Message{ Topic: "test", Partition: 0, Offset: 1 }); ok {
+        t.Errorf("Expecting no offset to store for a removed partition")
+    }
+    if n := tracker.pending(); n != 1 {
+        t.Errorf("Expecting 1 pending offset but got: %v", n)
+    }
+}
+
+
+func TestOffsetTracker_Generation(t *testing.T) {
+    t.Parallel()
+
+    topic   := "test"
+    tracker := newOffsetTracker()
+    old     := &Message{ Topic: "test", Partition: 0, Offset: 5 }
+    tracker.start(old)
+
+    // revoked and reassigned, offset 5 is read again in a new generation
+    tracker.remove([]TopicPartition{ { Topic: &topic, Partition: 0 } })
+    gen := tracker.generation("test", 0)
+    if gen != 1 {
+        t.Fatalf("Expecting generation 1 after a remove but got: %v", gen)
+    }
+    tracker.start(&Message{ Topic: "test", Partition: 0, Offset: 5, gen: gen })
+    tracker.start(&Message{ Topic: "test", Partition: 0, Offset: 6, gen: gen })
+
+    if _, ok := tracker.finish(old); ok {
+        t.Errorf("Expecting no offset to store for a message of an earlier generation")
+    }
+    if _, ok := tracker.finish(&Message{ Topic: "test", Partition: 0, Offset: 9, gen: gen }); ok {
+        t.Errorf("Expecting no offset to store for an untracked offset")
+    }
+    if n := tracker.pending(); n != 2 {
+        t.Errorf("Expecting 2 pending offsets but got: %v", n)
+    }
+
+    tracker.start(old)
+    if n := tracker.pending(); n != 2 {
+        t.Errorf("Expecting a message of an earlier generation not to be tracked but got: %v pending", n)
+    }
+
+    if tp, ok := tracker.finish(&Message{ Topic: "test", Partition: 0, Offset: 5, gen: gen }); ! ok || tp.Offset != 5 {
+        t.Errorf("Expecting offset 5 to be stored but got: %v %v", tp, ok)
+    }
+}
@@ -0,0 +1,206 @@
+/** kafka.Producer transactions
+  *
+  *  The idempotent and transactional producer modes. A site with a
+  *  TransactionalId produces each record directly within the open
+  *  transaction, and consumed offsets may be committed as part of the
+  *  transaction for exactly-once consume-transform-produce.
+  *
+  *  Copyright (c) 2023-2026 Timothy C. Arland <tcarland at gmail dot com>
+ **/
+package kafka
+
+import (
+    "context"
+    "errors"
+
+    "github.com/confluentinc/confluent-kafka-go/v2/kafka"
+)
+
+
+// ConsumerGroupMetadata identifies the consumer group generation of
+// offsets sent to a transaction.
+type ConsumerGroupMetadata = kafka.ConsumerGroupMetadata
+
+var errNotTransactional = errors.New("producer has no transactionalid")
+
+// -----------------------------------
+
+// transactional is true when the site sets a TransactionalId
+func (p *Producer) transactional() bool {
+    return p.site.TxnId != ""
+}
+
+
+// clientConfig returns the client settings for the site producer mode,
+// transactions imply idempotence.
+func (p *Producer) clientConfig() kafka.ConfigMap {
+    managed := kafka.ConfigMap{}
+
+    if p.site.Idempotent || p.transactional() {
+        managed["enable.idempotence"] = true
+    }
+    if p.transactional() {
+        managed["transactional.id"] = p.site.TxnId
+    }
+    return managed
+}
+
+
+// client returns the producer handle while Produce() is running
+func (p *Producer) client() (*kafka.Producer, error) {
+    p.lock.Lock()
+    defer p.lock.Unlock()
+
+    if p.producer == nil {
+        return nil, errors.New("producer is not running")
+    }
+    return p.producer, nil
+}
+
+
+// txnClient returns the client of a running transactional Producer
+func (p *Producer) txnClient(op string) (*kafka.Producer, error) {
+    if ! p.transactional() {
+        return nil, newError(op, p.topic, ErrConfig, errNotTransactional)
+    }
+    producer, err := p.client()
+    if err != nil {
+        return nil, newError(op, p.topic, ErrState, err)
+    }
+    return producer, nil
+}
+
+// -----------------------------------
+
+/** InitTransactions creates the client and registers the site
+  * TransactionalId with the cluster, fencing off any earlier producer
+  * of the same id. It may be called before starting Produce() to know
+  * the Producer is ready for BeginTxn(), otherwise Produce() does so.
+ **/
+func (p *Producer) InitTransactions(ctx context.Context) error {
+    if ! p.transactional() {
+        return newError("Producer.InitTransactions", p.topic, ErrConfig, errNotTransactional)
+    }
+    _, err := p.start(ctx)
+    return err
+}
+
+
+// BeginTxn begins a transaction, records sent until CommitTxn() or
+// AbortTxn() are written as part of it.
+func (p *Producer) BeginTxn() error {
+    producer, err := p.txnClient("Producer.BeginTxn")
+    if err != nil {
+        return err
+    }
+    if err := producer.BeginTransaction(); err != nil {
+        return kafkaError("Producer.BeginTxn", p.topic, err)
+    }
+    return nil
+}
+
+
+/** CommitTxn flushes the records of the transaction and commits it,
+  * retrying retriable errors until the context is done. An error for
+  * which TxnRequiresAbort() is true requires calling AbortTxn(), while
+  * an ErrFatal error, such as when fenced by a newer producer of the
+  * same id, requires a new Producer.
+ **/
+func (p *Producer) CommitTxn(ctx context.Context) error {
+    producer, err := p.txnClient("Producer.CommitTxn")
+    if err != nil {
+        return err
+    }
+
+    for {
+        err = producer.CommitTransaction(ctx)
+
+        var kerr kafka.Error
+        if err == nil || ! errors.As(err, &kerr) || ! kerr.IsRetriable() || ctx.Err() != nil {
+            break
+        }
+    }
+    if err != nil {
+        return kafkaError("Producer.CommitTxn", p.topic, err)
+    }
+    return nil
+}
+
+
+// AbortTxn aborts the transaction, purging records not yet delivered
+func (p *Producer) AbortTxn(ctx context.Context) error {
+    producer, err := p.txnClient("Producer.AbortTxn")
+    if err != nil {
+        return err
+    }
+    if err := producer.AbortTransaction(ctx); err != nil {
+        return kafkaError("Producer.AbortTxn", p.topic, err)
+    }
+    return nil
+}
+
+
+/** SendOffsetsToTxn adds consumed offsets to the transaction, to be
+  * committed for the consumer group only if the transaction commits.
+  * The offsets are those of the next messages to consume, as given
+  * by NextOffsets(), and the group is from Consumer.GroupMetadata().
+ **/
+func (p *Producer) SendOffsetsToTxn(ctx context.Context, offsets []TopicPartition, group *ConsumerGroupMetadata) error {
+    producer, err := p.txnClient("Producer.SendOffsetsToTxn")
+    if err != nil {
+        return err
+    }
+    if err := producer.SendOffsetsToTransaction(ctx, offsets, group); err != nil {
+        return kafkaError("Producer.SendOffsetsToTxn", p.topic, err)
+    }
+    return nil
+}
+
+
+// TxnRequiresAbort returns true for a transaction error after which
+// the transaction must be aborted with AbortTxn().
+func TxnRequiresAbort(err error) bool {
+    var kerr kafka.Error
+    return errors.As(err, &kerr) && kerr.TxnRequiresAbort()
+}
+
+// -----------------------------------
+
+/** NextOffsets returns the offset following the last of the given
+  * messages for each of their partitions, being the offsets to commit
+  * once the messages are processed.
+ **/
+func NextOffsets(msgs []*Message) []TopicPartition {
+    offsets := make(map[string]kafka.TopicPartition)
+
+    for _, m := range msgs {
+        key := partitionKey(m.Topic, m.Partition)
+        if tp, ok := offsets[key]; ! ok || int64(tp.Offset) < m.Offset + 1 {
+            tp = m.topicPartition()
+            tp.Offset++
+            offsets[key] = tp
+        }
+    }
+
+    parts := make([]kafka.TopicPartition, 0, len(offsets))
+    for _, tp := range offsets {
+        parts = append(parts, tp)
+    }
+    return parts
+}
+
+
+// GroupMetadata returns the consumer group metadata of the running
+// Consumer, for committing its offsets with Producer.SendOffsetsToTxn().
+func (c *Consumer) GroupMetadata() (*ConsumerGroupMetadata, error) {
+    consumer, err := c.client()
+    if err != nil {
+        return nil, newError("Consumer.GroupMetadata", c.name, ErrState, err)
+    }
+
+    md, err := consumer.GetConsumerGroupMetadata()
+    if err != nil {
+        return nil, kafkaError("Consumer.GroupMetadata", c.name, err)
+    }
+    return md, nil
+}
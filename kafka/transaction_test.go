@@ -0,0 +1,93 @@
+package kafka
+
+import (
+    "context"
+    "errors"
+    "testing"
+
+    "github.com/tcarland/tca-kafka-go/config"
+)
+
+
+func TestNextOffsets(t *testing.T) {
+    t.Parallel()
+
+    msgs := []*Message{
+        { Topic: "a", Partition: 0, Offset: 5 },
+        { Topic: "a", Partition: 0, Offset: 7 },
+        { Topic: "a", Partition: 1, Offset: 2 },
+        { Topic: "b", Partition: 0, Offset: 9 },
+        { Topic: "a", Partition: 0, Offset: 6 },
+    }
+    expected := map[string]int64{ "a/0": 8, "a/1": 3, "b/0": 10 }
+
+    offsets := NextOffsets(msgs)
+    if len(offsets) != len(expected) {
+        t.Fatalf("Expecting %v partitions but got: %v", len(expected), offsets)
+    }
+    for _, tp := range offsets {
+        key := partitionKey(*tp.Topic, tp.Partition)
+        if int64(tp.Offset) != expected[key] {
+            t.Errorf("Expecting offset %v for %v but got: %v", expected[key], key, tp.Offset)
+        }
+    }
+}
+
+
+func TestProducer_ClientConfig(t *testing.T) {
+    t.Parallel()
+
+    testCases := []struct {
+        name        string
+        idempotent  bool
+        txnid       string
+        exidem      bool
+        extxn       bool
+    }{
+        {"Default producer", false, "", false, false},
+        {"Idempotent producer", true, "", true, false},
+        {"Transactional producer", false, "txn-1", true, true},
+    }
+
+    for _, tc := range testCases {
+        t.Run(tc.name, func(t *testing.T) {
+            site := config.NewKafkaSite("localhost:9092", "test", "")
+            site.Idempotent = tc.idempotent
+            site.TxnId      = tc.txnid
+
+            cfg := NewSiteProducer(site).clientConfig()
+            if _, ok := cfg["enable.idempotence"]; ok != tc.exidem {
+                t.Errorf("Expecting idempotence %v but got: %v", tc.exidem, cfg)
+            }
+            if _, ok := cfg["transactional.id"]; ok != tc.extxn {
+                t.Errorf("Expecting a transactional id %v but got: %v", tc.extxn, cfg)
+            }
+        })
+    }
+}
+
+
+func TestProducer_TxnState(t *testing.T) {
+    t.Parallel()
+
+    site := config.NewKafkaSite("localhost:9092", "test", "")
+    plain := NewSiteProducer(site)
+
+    if err := plain.BeginTxn(); ! errors.Is(err, ErrConfig) {
+        t.Errorf("Expecting an ErrConfig error without a transactionalid but got: %v", err)
+    }
+
+    txnsite := config.NewKafkaSite("localhost:9092", "test", "")
+    txnsite.TxnId = "txn-1"
+    txn := NewSiteProducer(txnsite)
+
+    if err := txn.BeginTxn(); ! errors.Is(err, ErrState) {
+        t.Errorf("Expecting an ErrState error when not running but got: %v", err)
+    }
+    if err := txn.CommitTxn(context.Background()); ! errors.Is(err, ErrState) {
+        t.Errorf("Expecting an ErrState error when not running but got: %v", err)
+    }
+    if _, err := NewConsumer("test", site).GroupMetadata(); ! errors.Is(err, ErrState) {
+        t.Errorf("Expecting an ErrState error when not running but got: %v", err)
+    }
+}
@@ -0,0 +1,122 @@
+/** kafka.Consumer worker pool
+  *
+  *  Parallel processing of messages by the site number of Workers.
+  *  Messages are assigned to a worker by a hash of their key, or of
+  *  their partition when there is no key, so messages of the same key
+  *  are handled in order while different keys are handled in parallel.
+  *
+  *  Copyright (c) 2023-2026 Timothy C. Arland <tcarland at gmail dot com>
+ **/
+package kafka
+
+import (
+    "context"
+    "hash/fnv"
+    "log"
+    "strconv"
+    "sync"
+)
+
+
+// workerIndex returns the worker of a message among n workers
+func workerIndex(m *Message, n int) int {
+    h := fnv.New32a()
+    if len(m.Key) > 0 {
+        h.Write(m.Key)
+    } else {
+        h.Write([]byte(m.Topic))
+        h.Write([]byte(strconv.Itoa(int(m.Partition))))
+    }
+    return int(h.Sum32() % uint32(n))
+}
+
+
+/** processWorkers is the Process() loop of worker mode, dispatching
+  * each message to the queue of its worker. Offsets are tracked per
+  * partition and stored up to the lowest unfinished offset.
+ **/
+func (c *Consumer) processWorkers(ctx context.Context) {
+    queues := make([]chan *Message, c.site.Workers)
+    wg     := sync.WaitGroup{}
+
+    for i := range queues {
+        queues[i] = make(chan *Message, max(c.site.HighWater, 1))
+        wg.Add(1)
+        go func(q chan *Message) {
+            defer wg.Done()
+            c.worker(ctx, q)
+        }(queues[i])
+    }
+
+    for c.active {
+        select {
+        case <- ctx.Done():
+            log.Println("Consumer.Process() Context done")
+            c.active = false
+        case m, ok := <-c.bpc:
+            if ! ok {
+                c.active = false
+                break
+            }
+            c.checkReset()
+            if c.passedOver(m) {
+                c.release()
+                continue
+            }
+            c.tracker.start(m)
+
+            select {
+            case queues[workerIndex(m, len(queues))] <- m:
+            case <- ctx.Done():
+                c.active = false
+            }
+        }
+    }
+
+    for _, q := range queues {
+        close(q)
+    }
+    wg.Wait()
+}
+
+
+// worker handles the messages of its queue until closed or the context is done
+func (c *Consumer) worker(ctx context.Context, q chan *Message) {
+    for {
+        select {
+        case <- ctx.Done():
+            return
+        case m, ok := <-q:
+            if ! ok {
+                return
+            }
+            c.work(ctx, m)
+        }
+    }
+}
+
+
+/** work dispatches a message to the Handler and finishes its offset.
+  * As with Process(), a failed message is not finished and its partition
+  * is rewound, while messages queued before a rewind are passed over.
+ **/
+func (c *Consumer) work(ctx context.Context, m *Message) {
+    if c.passedOver(m) {
+        c.release()
+        return
+    }
+
+    err := c.dispatch(ctx, m)
+    c.release()
+
+    if err != nil {
+        log.Printf("Consumer.Process() handler error: %v", err)
+        c.sendError(newError("Consumer.Process", c.name, ErrHandler, err))
+        c.rewind(ctx, m)
+        return
+    }
+
+    if tp, ok := c.tracker.finish(m); ok {
+        c.ack(tp)
+    }
+}
@@ -0,0 +1,98 @@
+package kafka
+
+import (
+    "context"
+    "fmt"
+    "sync"
+    "testing"
+    "time"
+
+    "github.com/tcarland/tca-kafka-go/config"
+)
+
+
+func TestWorkerIndex(t *testing.T) {
+    t.Parallel()
+
+    a := &Message{ Topic: "test", Partition: 0, Key: []byte("key1") }
+    b := &Message{ Topic: "test", Partition: 5, Key: []byte("key1") }
+    c := &Message{ Topic: "test", Partition: 5 }
+    d := &Message{ Topic: "test", Partition: 5 }
+
+    if workerIndex(a, 8) != workerIndex(b, 8) {
+        t.Errorf("Expecting messages of the same key on the same worker")
+    }
+    if workerIndex(c, 8) != workerIndex(d, 8) {
+        t.Errorf("Expecting messages without a key of the same partition on the same worker")
+    }
+    for i := 0; i < 100; i++ {
+        m := &Message{ Key: []byte(fmt.Sprintf("key%d", i)) }
+        if n := workerIndex(m, 8); n < 0 || n >= 8 {
+            t.Fatalf("Expecting a worker index below 8 but got: %v", n)
+        }
+    }
+}
+
+
+func TestConsumer_ProcessWorkers(t *testing.T) {
+    t.Parallel()
+
+    const nkeys, nmsgs = 5, 20
+
+    site := config.NewKafkaSite("localhost:9092", "test", "grp1")
+    site.Workers   = 4
+    site.HighWater = 0
+
+    lock  := sync.Mutex{}
+    seen  := make(map[string][]int64)
+    count := make(chan struct{}, nkeys * nmsgs)
+
+    c := NewConsumer("test", site)
+    c.SetHandler(HandlerFunc(func(ctx context.Context, m *Message) error {
+        lock.Lock()
+        seen[string(m.Key)] = append(seen[string(m.Key)], m.Offset)
+        lock.Unlock()
+        count <- struct{}{}
+        return nil
+    }))
+
+    ctx, cancel := context.WithCancel(context.Background())
+    defer cancel()
+    go c.Process(ctx)
+
+    for i := 0; i < nkeys * nmsgs; i++ {
+        c.acquire()
+        c.bpc <- &Message{ Topic: "test", Offset: int64(i), Key: []byte(fmt.Sprintf("key%d", i % nkeys)) }
+    }
+
+    for i := 0; i < nkeys * nmsgs; i++ {
+        select {
+        case <-count:
+        case <-time.After(time.Second):
+            t.Fatalf("Timed out after %v of %v messages", i, nkeys * nmsgs)
+        }
+    }
+
+    lock.Lock()
+    defer lock.Unlock()
+
+    for key, offsets := range seen {
+        if len(offsets) != nmsgs {
+            t.Errorf("Expecting %v messages of '%v' but got: %v", nmsgs, key, len(offsets))
+        }
+        for i := 1; i < len(offsets); i++ {
+            if offsets[i] <= offsets[i-1] {
+                t.Errorf("Expecting the messages of '%v' in order but got: %v", key, offsets)
+                break
+            }
+        }
+    }
+
+    deadline := time.Now().Add(time.Second)
+    for c.tracker.pending() != 0 && time.Now().Before(deadline) {
+        time.Sleep(time.Millisecond)
+    }
+    if n := c.tracker.pending(); n != 0 {
+        t.Errorf("Expecting no pending offsets but got: %v", n)
+    }
+}
@@ -0,0 +1,208 @@
+/** metrics.Collector
+  *
+  *  A Prometheus collector of the kafka client metrics. The Collector
+  *  is the kafka.Metrics of the Consumers and Producers it is set on,
+  *  and reports the size of watched SyncLists and the hits and misses
+  *  of watched BufferPools when scraped.
+  *
+  *  Copyright (c) 2023-2026 Timothy C. Arland <tcarland at gmail dot com>
+ **/
+package metrics
+
+import (
+    "errors"
+    "sync"
+    "time"
+
+    "github.com/tcarland/tca-kafka-go/kafka"
+    "github.com/tcarland/tca-kafka-go/utils"
+
+    "github.com/prometheus/client_golang/prometheus"
+)
+
+
+const subsystem = "kafka"
+
+// HandlerBuckets are the handler latency histogram buckets in seconds
+var HandlerBuckets = []float64{ .0005, .001, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10 }
+
+
+/** Collector exports the kafka client metrics, and is registered on a
+  * caller supplied registry. Metric names are prefixed by the namespace
+  * given, if any, and 'kafka'.
+ **/
+type Collector struct {
+    consumed      *prometheus.CounterVec
+    consumedBytes *prometheus.CounterVec
+    produced      *prometheus.CounterVec
+    producedBytes *prometheus.CounterVec
+    failures      *prometheus.CounterVec
+    queueFull     *prometheus.CounterVec
+    latency       *prometheus.HistogramVec
+    errors        *prometheus.CounterVec
+    listSize      *prometheus.Desc
+    poolHits      *prometheus.Desc
+    poolMisses    *prometheus.Desc
+    lock           sync.Mutex
+    lists          map[string]*utils.SyncList
+    pools          map[string]*utils.BufferPool
+}
+
+// -----------------------------------
+
+func NewCollector(namespace string) *Collector {
+    return new(Collector).InitCollector(namespace)
+}
+
+
+func (c *Collector) InitCollector(namespace string) *Collector {
+    counter := func(name string, help string, labels ...string) *prometheus.CounterVec {
+        return prometheus.NewCounterVec(prometheus.CounterOpts{
+            Namespace: namespace,
+            Subsystem: subsystem,
+            Name:      name,
+            Help:      help,
+        }, labels)
+    }
+    desc := func(name string, help string) *prometheus.Desc {
+        return prometheus.NewDesc(prometheus.BuildFQName(namespace, subsystem, name), help, []string{ "name" }, nil)
+    }
+
+    c.consumed      = counter("consumed_messages_total", "Messages read by the consumer.", "client", "topic")
+    c.consumedBytes = counter("consumed_bytes_total", "Value bytes read by the consumer.", "client", "topic")
+    c.produced      = counter("produced_messages_total", "Messages delivered by the producer.", "client", "topic")
+    c.producedBytes = counter("produced_bytes_total", "Value bytes delivered by the producer.", "client", "topic")
+    c.failures      = counter("delivery_failures_total", "Messages that failed delivery.", "client", "topic")
+    c.queueFull     = counter("queue_full_total", "Messages rejected by a full producer queue.", "client", "topic")
+    c.errors        = counter("errors_total", "Errors sent to the client Errors() channel by kind.", "client", "kind")
+
+    c.latency = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+        Namespace: namespace,
+        Subsystem: subsystem,
+        Name:      "handler_latency_seconds",
+        Help:      "Latency of the consumer Handler or BatchHandler calls.",
+        Buckets:   HandlerBuckets,
+    }, []string{ "client", "result" })
+
+    c.listSize   = desc("synclist_size", "Number of items in the SyncList.")
+    c.poolHits   = desc("bufferpool_hits_total", "BufferPool Get() calls given a pooled buffer.")
+    c.poolMisses = desc("bufferpool_misses_total", "BufferPool Get() calls that allocated a buffer.")
+
+    c.lists = make(map[string]*utils.SyncList)
+    c.pools = make(map[string]*utils.BufferPool)
+    return c
+}
+
+
+// WatchSyncList reports the size of the list, such as that of
+// Consumer.GetSyncList(), under the given name.
+func (c *Collector) WatchSyncList(name string, list *utils.SyncList) {
+    c.lock.Lock()
+    defer c.lock.Unlock()
+    c.lists[name] = list
+}
+
+
+// WatchBufferPool reports the hits and misses of the pool, such as
+// that of Producer.GetBufferPool(), under the given name.
+func (c *Collector) WatchBufferPool(name string, pool *utils.BufferPool) {
+    c.lock.Lock()
+    defer c.lock.Unlock()
+    c.pools[name] = pool
+}
+
+// -----------------------------------
+
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+    for _, v := range c.counters() {
+        v.Describe(ch)
+    }
+    c.latency.Describe(ch)
+    ch <- c.listSize
+    ch <- c.poolHits
+    ch <- c.poolMisses
+}
+
+
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+    for _, v := range c.counters() {
+        v.Collect(ch)
+    }
+    c.latency.Collect(ch)
+
+    c.lock.Lock()
+    defer c.lock.Unlock()
+
+    for name, list := range c.lists {
+        list.Lock()
+        size := list.Size()
+        list.Unlock()
+        ch <- prometheus.MustNewConstMetric(c.listSize, prometheus.GaugeValue, float64(size), name)
+    }
+    for name, pool := range c.pools {
+        ch <- prometheus.MustNewConstMetric(c.poolHits, prometheus.CounterValue, float64(pool.Hits()), name)
+        ch <- prometheus.MustNewConstMetric(c.poolMisses, prometheus.CounterValue, float64(pool.Misses()), name)
+    }
+}
+
+
+func (c *Collector) counters() []*prometheus.CounterVec {
+    return []*prometheus.CounterVec{
+        c.consumed, c.consumedBytes, c.produced, c.producedBytes, c.failures, c.queueFull, c.errors,
+    }
+}
+
+// -----------------------------------
+
+func (c *Collector) Consumed(client string, topic string, bytes int) {
+    c.consumed.WithLabelValues(client, topic).Inc()
+    c.consumedBytes.WithLabelValues(client, topic).Add(float64(bytes))
+}
+
+
+func (c *Collector) Handled(client string, latency time.Duration, err error) {
+    result := "ok"
+    if err != nil {
+        result = "error"
+    }
+    c.latency.WithLabelValues(client, result).Observe(latency.Seconds())
+}
+
+
+func (c *Collector) Produced(client string, topic string, bytes int) {
+    c.produced.WithLabelValues(client, topic).Inc()
+    c.producedBytes.WithLabelValues(client, topic).Add(float64(bytes))
+}
+
+
+func (c *Collector) DeliveryFailed(client string, topic string) {
+    c.failures.WithLabelValues(client, topic).Inc()
+}
+
+
+func (c *Collector) QueueFull(client string, topic string) {
+    c.queueFull.WithLabelValues(client, topic).Inc()
+}
+
+
+func (c *Collector) Error(client string, err error) {
+    c.errors.WithLabelValues(client, errorKind(err)).Inc()
+}
+
+
+// errorKind returns the label of the kafka error kind of err
+func errorKind(err error) string {
+    switch {
+    case errors.Is(err, kafka.ErrConfig):
+        return "config"
+    case errors.Is(err, kafka.ErrFatal):
+        return "fatal"
+    case errors.Is(err, kafka.ErrBroker):
+        return "broker"
+    case errors.Is(err, kafka.ErrHandler):
+        return "handler"
+    case errors.Is(err, kafka.ErrState):
+        return "state"
+    }
+    return "other"
+}
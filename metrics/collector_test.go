@@ -0,0 +1,107 @@
+package metrics
+
+import (
+    "errors"
+    "testing"
+    "time"
+
+    "github.com/tcarland/tca-kafka-go/kafka"
+    "github.com/tcarland/tca-kafka-go/utils"
+
+    "github.com/prometheus/client_golang/prometheus"
+)
+
+
+// gather returns the sum of the counter, gauge or histogram count
+// values of each metric family.
+func gather(t *testing.T, reg *prometheus.Registry) map[string]float64 {
+    families, err := reg.Gather()
+    if err != nil {
+        t.Fatalf("Unexpected error gathering metrics: %v", err)
+    }
+
+    values := make(map[string]float64)
+    for _, mf := range families {
+        for _, m := range mf.GetMetric() {
+            values[mf.GetName()] += m.GetCounter().GetValue() + m.GetGauge().GetValue() +
+                float64(m.GetHistogram().GetSampleCount())
+        }
+    }
+    return values
+}
+
+
+func TestCollector(t *testing.T) {
+    t.Parallel()
+
+    col := NewCollector("app")
+    reg := prometheus.NewRegistry()
+    reg.MustRegister(col)
+
+    list := utils.NewSyncList()
+    list.PushBack(1)
+    list.PushBack(2)
+    pool := utils.NewBufferPool(2)
+    pool.Get()
+
+    col.WatchSyncList("orders", list)
+    col.WatchBufferPool("events", pool)
+
+    col.Consumed("orders", "orders", 100)
+    col.Consumed("orders", "orders", 50)
+    col.Handled("orders", time.Millisecond, nil)
+    col.Handled("orders", time.Second, errors.New("failed"))
+    col.Produced("events", "events", 10)
+    col.DeliveryFailed("events", "events")
+    col.QueueFull("events", "events")
+    col.Error("orders", &kafka.Error{ Op: "Consumer.Process", Kind: kafka.ErrHandler })
+
+    testCases := []struct {
+        name     string
+        metric   string
+        exvalue  float64
+    }{
+        {"Messages consumed", "app_kafka_consumed_messages_total", 2},
+        {"Bytes consumed", "app_kafka_consumed_bytes_total", 150},
+        {"Handler calls", "app_kafka_handler_latency_seconds", 2},
+        {"Messages produced", "app_kafka_produced_messages_total", 1},
+        {"Delivery failures", "app_kafka_delivery_failures_total", 1},
+        {"Queue full", "app_kafka_queue_full_total", 1},
+        {"Errors", "app_kafka_errors_total", 1},
+        {"SyncList size", "app_kafka_synclist_size", 2},
+        {"BufferPool misses", "app_kafka_bufferpool_misses_total", 1},
+    }
+
+    values := gather(t, reg)
+
+    for _, tc := range testCases {
+        t.Run(tc.name, func(t *testing.T) {
+            if v := values[tc.metric]; v != tc.exvalue {
+                t.Errorf("Expecting %s of %v but got: %v", tc.metric, tc.exvalue, v)
+            }
+        })
+    }
+}
+
+
+func TestErrorKind(t *testing.T) {
+    t.Parallel()
+
+    testCases := []struct {
+        name    string
+        err     error
+        exkind  string
+    }{
+        {"Config error", &kafka.Error{ Kind: kafka.ErrConfig }, "config"},
+        {"State error", &kafka.Error{ Kind: kafka.ErrState }, "state"},
+        {"Other error", errors.New("other"), "other"},
+    }
+
+    for _, tc := range testCases {
+        t.Run(tc.name, func(t *testing.T) {
+            if kind := errorKind(tc.err); kind != tc.exkind {
+                t.Errorf("Expecting kind %v but got: %v", tc.exkind, kind)
+            }
+        })
+    }
+}
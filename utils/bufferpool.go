@@ -6,10 +6,13 @@ package utils
 
 import (
     "bytes"
+    "sync/atomic"
 )
 
 type BufferPool struct {
-    c chan *bytes.Buffer
+    c       chan *bytes.Buffer
+    hits    atomic.Uint64
+    misses  atomic.Uint64
 }
 
 
@@ -20,8 +23,10 @@ func NewBufferPool(size int) (pool *BufferPool) {
 func (pool *BufferPool) Get() (buffer *bytes.Buffer) {
     select {
     case buffer = <-pool.c:
+        pool.hits.Add(1)
     default:
         buffer = bytes.NewBuffer([]byte{})
+        pool.misses.Add(1)
     }
     return
 }
@@ -37,3 +42,15 @@ func (pool *BufferPool) Put(buffer *bytes.Buffer) {
 func (pool *BufferPool) Size() int {
     return len(pool.c)
 }
+
+
+// Hits returns the number of Get() calls given a pooled buffer
+func (pool *BufferPool) Hits() uint64 {
+    return pool.hits.Load()
+}
+
+
+// Misses returns the number of Get() calls that allocated a buffer
+func (pool *BufferPool) Misses() uint64 {
+    return pool.misses.Load()
+}
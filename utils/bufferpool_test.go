@@ -142,3 +142,19 @@ func TestBufferPool_PutFull(t *testing.T) {
         })
     }
 }
+
+
+func TestBufferPool_HitsMisses(t *testing.T) {
+    t.Parallel()
+
+    pool := NewBufferPool(4)
+    pool.Put(bytes.NewBufferString("First Buffer"))
+
+    pool.Get()
+    pool.Get()
+    pool.Get()
+
+    if pool.Hits() != 1 || pool.Misses() != 2 {
+        t.Errorf("Expecting 1 hit and 2 misses but got: %v %v", pool.Hits(), pool.Misses())
+    }
+}